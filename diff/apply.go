@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"errors"
+
+	"layeh.com/m"
+)
+
+// ErrInvalidPath is returned by Apply when an operation's path does not
+// locate a node.
+var ErrInvalidPath = errors.New("diff: invalid path")
+
+// Apply returns the result of applying ops to nodes.
+//
+// nodes is not modified; the nodes along the path of each operation are
+// copied. Attributes added by SetAttr are appended to the attributes of the
+// element.
+func Apply(nodes []*m.Node, ops []Op) ([]*m.Node, error) {
+	nodes = append([]*m.Node(nil), nodes...)
+	for _, op := range ops {
+		if op.Type == Reset {
+			if len(op.Path) != 0 {
+				return nil, ErrInvalidPath
+			}
+			nodes = nil
+			continue
+		}
+		var err error
+		if nodes, err = apply(nodes, op, op.Path); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// apply applies op to the node at path of list, which is owned by the
+// caller.
+func apply(list []*m.Node, op Op, path []int) ([]*m.Node, error) {
+	if len(path) == 0 {
+		return nil, ErrInvalidPath
+	}
+	index := path[0]
+	if len(path) > 1 {
+		if index < 0 || index >= len(list) || list[index].Type != m.ElementNode {
+			return nil, ErrInvalidPath
+		}
+		n := copyNode(list[index])
+		var err error
+		if n.Children, err = apply(n.Children, op, path[1:]); err != nil {
+			return nil, err
+		}
+		list[index] = n
+		return list, nil
+	}
+
+	if op.Type == Insert {
+		if index < 0 || index > len(list) || op.Node == nil {
+			return nil, ErrInvalidPath
+		}
+		list = append(list, nil)
+		copy(list[index+1:], list[index:])
+		list[index] = op.Node
+		return list, nil
+	}
+
+	if index < 0 || index >= len(list) {
+		return nil, ErrInvalidPath
+	}
+	switch op.Type {
+	case Remove:
+		list = append(list[:index], list[index+1:]...)
+	case Replace:
+		if op.Node == nil {
+			return nil, ErrInvalidPath
+		}
+		list[index] = op.Node
+	case SetText:
+		if list[index].Type != m.TextNode {
+			return nil, ErrInvalidPath
+		}
+		n := copyNode(list[index])
+		n.Data = op.Value
+		list[index] = n
+	case SetAttr, RemoveAttr:
+		if list[index].Type != m.ElementNode {
+			return nil, ErrInvalidPath
+		}
+		n := copyNode(list[index])
+		var attributes []m.Attribute
+		set := false
+		for _, attr := range n.Attributes {
+			if attr.Key == op.Key {
+				if op.Type == RemoveAttr {
+					continue
+				}
+				attr.Value = op.Value
+				set = true
+			}
+			attributes = append(attributes, attr)
+		}
+		if op.Type == SetAttr && !set {
+			attributes = append(attributes, m.Attribute{Key: op.Key, Value: op.Value})
+		}
+		n.Attributes = attributes
+		list[index] = n
+	default:
+		return nil, errors.New("diff: invalid operation type " + op.Type.String())
+	}
+	return list, nil
+}
+
+func copyNode(n *m.Node) *m.Node {
+	c := *n
+	c.Children = append([]*m.Node(nil), n.Children...)
+	return &c
+}
@@ -0,0 +1,270 @@
+// Package diff computes the differences between two element trees.
+//
+// The differences are described as a list of operations that transform the
+// nodes of one expanded element tree into the nodes of another.
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"layeh.com/m"
+)
+
+// OpType is the type of an Op.
+type OpType int
+
+// Operation types.
+const (
+	// Insert inserts Node so that it is located at Path.
+	Insert OpType = iota + 1
+	// Remove removes the node at Path.
+	Remove
+	// Replace replaces the node at Path with Node.
+	Replace
+	// SetAttr sets the attribute Key of the element at Path to Value.
+	SetAttr
+	// RemoveAttr removes the attribute Key of the element at Path.
+	RemoveAttr
+	// SetText sets the data of the text node at Path to Value. Raw nodes
+	// whose HTML changes are replaced instead.
+	SetText
+	// Reset removes all nodes. Its Path is empty. Diff does not return
+	// Reset operations, but they start a list of operations that does not
+	// depend on the previous tree, such as the first patch of a live
+	// session.
+	Reset
+)
+
+var opTypeNames = [...]string{
+	Insert:     "insert",
+	Remove:     "remove",
+	Replace:    "replace",
+	SetAttr:    "set-attr",
+	RemoveAttr: "remove-attr",
+	SetText:    "set-text",
+	Reset:      "reset",
+}
+
+func (t OpType) String() string {
+	if t > 0 && int(t) < len(opTypeNames) {
+		return opTypeNames[t]
+	}
+	return "OpType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Op is an operation that modifies a node tree.
+//
+// Path locates a node: the first index is into the top-level nodes, the
+// second into the children of that node, and so on. Paths refer to the tree
+// as modified by the operations that precede the Op.
+type Op struct {
+	Type OpType
+	Path []int
+
+	// Node is set for Insert and Replace.
+	Node *m.Node
+
+	// Key is set for SetAttr and RemoveAttr.
+	Key string
+	// Value is set for SetAttr and SetText.
+	Value string
+}
+
+func (op Op) String() string {
+	var b strings.Builder
+	b.WriteString(op.Type.String())
+	b.WriteString(" [")
+	for i, index := range op.Path {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.Itoa(index))
+	}
+	b.WriteByte(']')
+	switch op.Type {
+	case Insert, Replace:
+		b.WriteByte(' ')
+		b.WriteString(m.RenderString(op.Node))
+	case SetAttr:
+		fmt.Fprintf(&b, " %s=%q", op.Key, op.Value)
+	case RemoveAttr:
+		b.WriteByte(' ')
+		b.WriteString(op.Key)
+	case SetText:
+		fmt.Fprintf(&b, " %q", op.Value)
+	}
+	return b.String()
+}
+
+// Diff returns the operations that transform the expanded tree of a into
+// the expanded tree of b.
+//
+// A non-nil error is returned if either element could not be expanded.
+func Diff(a, b m.Element) ([]Op, error) {
+	aNodes, err := m.Nodes(a)
+	if err != nil {
+		return nil, err
+	}
+	bNodes, err := m.Nodes(b)
+	if err != nil {
+		return nil, err
+	}
+	return Nodes(aNodes, bNodes), nil
+}
+
+// Nodes returns the operations that transform the node tree a into b.
+//
+// Child lists are aligned so that as many identical nodes as possible are
+// kept, and then as many other nodes as possible are updated in place. The
+// order of attributes is
+// not considered.
+func Nodes(a, b []*m.Node) []Op {
+	var d differ
+	d.children(nil, a, b)
+	return d.Ops
+}
+
+type differ struct {
+	Ops []Op
+}
+
+func (d *differ) op(op Op) {
+	op.Path = append([]int(nil), op.Path...)
+	d.Ops = append(d.Ops, op)
+}
+
+func (d *differ) children(path []int, a, b []*m.Node) {
+	// score[i][j] is the best alignment score of a[i:] and b[j:]. A pair of
+	// nodes that can be updated in place scores 1, and a pair of identical
+	// nodes scores more than any number of updated pairs, since keeping it
+	// takes no operations while each update takes at least one.
+	identical := len(a) + len(b) + 1
+	pair := func(i, j int) int {
+		switch weight(a[i], b[j]) {
+		case 2:
+			return identical
+		case 1:
+			return 1
+		}
+		return 0
+	}
+	score := make([][]int, len(a)+1)
+	for i := range score {
+		score[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			s := score[i+1][j]
+			if score[i][j+1] > s {
+				s = score[i][j+1]
+			}
+			if w := pair(i, j); w > 0 && score[i+1][j+1]+w > s {
+				s = score[i+1][j+1] + w
+			}
+			score[i][j] = s
+		}
+	}
+
+	index := 0
+	removed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		path := append(path, index)
+		switch {
+		case i < len(a) && j < len(b) && pair(i, j) > 0 && score[i][j] == score[i+1][j+1]+pair(i, j):
+			d.node(path, a[i], b[j])
+			removed = false
+			index++
+			i++
+			j++
+		case i < len(a) && (j == len(b) || score[i][j] == score[i+1][j]):
+			d.op(Op{Type: Remove, Path: path})
+			removed = true
+			i++
+		default:
+			if last := len(d.Ops) - 1; removed {
+				d.Ops[last].Type = Replace
+				d.Ops[last].Node = b[j]
+			} else {
+				d.op(Op{Type: Insert, Path: path, Node: b[j]})
+			}
+			removed = false
+			index++
+			j++
+		}
+	}
+}
+
+func (d *differ) node(path []int, a, b *m.Node) {
+	if a.Type != m.ElementNode {
+		switch {
+		case a.Data == b.Data:
+		case a.Type == m.RawNode:
+			// Raw HTML must be parsed again, which setting the data of the
+			// text node that it may have been parsed to does not do.
+			d.op(Op{Type: Replace, Path: path, Node: b})
+		default:
+			d.op(Op{Type: SetText, Path: path, Value: b.Data})
+		}
+		return
+	}
+
+	for _, attr := range a.Attributes {
+		if _, ok := b.Attr(attr.Key); !ok {
+			d.op(Op{Type: RemoveAttr, Path: path, Key: attr.Key})
+		}
+	}
+	for _, attr := range b.Attributes {
+		if value, ok := a.Attr(attr.Key); !ok || value != attr.Value {
+			d.op(Op{Type: SetAttr, Path: path, Key: attr.Key, Value: attr.Value})
+		}
+	}
+	d.children(path, a.Children, b.Children)
+}
+
+// weight returns how well a and b align: 2 if they are identical, 1 if a
+// can be updated in place to b, and 0 otherwise.
+func weight(a, b *m.Node) int {
+	if a.Type != b.Type {
+		return 0
+	}
+	if a.Type == m.ElementNode {
+		if a.Namespace != b.Namespace || a.TagName != b.TagName {
+			return 0
+		}
+		aID, aOK := a.Attr("id")
+		bID, bOK := b.Attr("id")
+		if aOK && bOK && aID != bID {
+			return 0
+		}
+	}
+	if equal(a, b) {
+		return 2
+	}
+	return 1
+}
+
+func equal(a, b *m.Node) bool {
+	if a == b {
+		return true
+	}
+	if a.Type != b.Type || a.Namespace != b.Namespace || a.TagName != b.TagName || a.Data != b.Data {
+		return false
+	}
+	if len(a.Attributes) != len(b.Attributes) || len(a.Children) != len(b.Children) {
+		return false
+	}
+	for _, attr := range a.Attributes {
+		if value, ok := b.Attr(attr.Key); !ok || value != attr.Value {
+			return false
+		}
+	}
+	for i := range a.Children {
+		if !equal(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
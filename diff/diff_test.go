@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"layeh.com/m"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		A, B     m.Element
+		Expected []string
+	}{
+		{
+			m.M("p", m.T("Hello")),
+			m.M("p", m.T("Hello")),
+			nil,
+		},
+		{
+			m.M("p", m.T("Hello")),
+			m.M("p", m.T("World")),
+			[]string{`set-text [0 0] "World"`},
+		},
+		{
+			m.M("p.a[title=x]", m.T("Hello")),
+			m.M("p.b[data-id=1]", m.T("Hello")),
+			[]string{`remove-attr [0] title`, `set-attr [0] class="b"`, `set-attr [0] data-id="1"`},
+		},
+		{
+			m.M("ul", m.M("li", m.T("A")), m.M("li", m.T("B"))),
+			m.M("ul", m.M("li", m.T("B"))),
+			[]string{`remove [0 0]`},
+		},
+		{
+			m.M("ul", m.M("li", m.T("A")), m.M("li", m.T("C"))),
+			m.M("ul", m.M("li", m.T("A")), m.M("li", m.T("B")), m.M("li", m.T("C"))),
+			[]string{`insert [0 1] <li>B</li>`},
+		},
+		{
+			m.S(m.M("h1", m.T("Title")), m.M("p", m.T("Body"))),
+			m.S(m.M("h2", m.T("Title")), m.M("p", m.T("Body"))),
+			[]string{`replace [0] <h2>Title</h2>`},
+		},
+		{
+			m.M("div", m.M("p#a", m.T("A")), m.M("p#b", m.T("B"))),
+			m.M("div", m.M("p#b", m.T("B")), m.M("p#c", m.T("C"))),
+			[]string{`remove [0 0]`, `insert [0 1] <p id="c">C</p>`},
+		},
+		{
+			m.M("div", m.T("Hi "), m.M("b", m.T("there"))),
+			m.M("div", m.Raw("<hr>"), m.M("b", m.T("you"))),
+			[]string{`replace [0 0] <hr>`, `set-text [0 1 0] "you"`},
+		},
+		{
+			m.M("i", m.Raw("&#9733;"), m.T("a")),
+			m.M("i", m.Raw("&#9734;"), m.T("a")),
+			[]string{`replace [0 0] &#9734;`},
+		},
+		{
+			// Keeping an identical node takes fewer operations than
+			// updating two others in place.
+			m.M("ul", m.M("li", m.T("a")), m.T("x"), m.M("li#k", m.T("b"))),
+			m.M("ul", m.M("li#k", m.T("b")), m.M("li", m.T("c")), m.Raw("<hr>")),
+			[]string{`remove [0 0]`, `remove [0 0]`, `insert [0 1] <li>c</li>`, `insert [0 2] <hr>`},
+		},
+		{
+			nil,
+			m.S(m.M("p"), m.M("p")),
+			[]string{`insert [0] <p></p>`, `insert [1] <p></p>`},
+		},
+	}
+
+	for _, tt := range tests {
+		ops, err := Diff(tt.A, tt.B)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, op := range ops {
+			got = append(got, op.String())
+		}
+		if !reflect.DeepEqual(got, tt.Expected) {
+			t.Errorf("Diff(%s, %s)\ngot:\n%#v\nexpected:\n%#v", m.RenderString(tt.A), m.RenderString(tt.B), got, tt.Expected)
+		}
+
+		a, _ := m.Nodes(tt.A)
+		applied, err := Apply(a, ops)
+		if err != nil {
+			t.Errorf("Apply: %s", err)
+			continue
+		}
+		if got, expected := renderNodes(applied), m.RenderString(tt.B); got != expected {
+			t.Errorf("Apply(%s)\ngot:\n%s\nexpected:\n%s", m.RenderString(tt.A), got, expected)
+		}
+		if got, expected := renderNodes(a), m.RenderString(tt.A); got != expected {
+			t.Errorf("Apply modified its input\ngot:\n%s\nexpected:\n%s", got, expected)
+		}
+	}
+}
+
+func TestApply_invalidPath(t *testing.T) {
+	nodes, _ := m.Nodes(m.M("p", m.T("Hello"), m.Raw("<br>")))
+	tests := []Op{
+		{Type: Remove, Path: nil},
+		{Type: Remove, Path: []int{1}},
+		{Type: Insert, Path: []int{2}, Node: &m.Node{Type: m.TextNode}},
+		{Type: SetText, Path: []int{0}, Value: "x"},
+		{Type: SetText, Path: []int{0, 1}, Value: "x"},
+		{Type: SetAttr, Path: []int{0, 0}, Key: "x"},
+		{Type: Remove, Path: []int{0, 0, 0}},
+		{Type: Reset, Path: []int{0}},
+	}
+	for _, op := range tests {
+		if _, err := Apply(nodes, []Op{op}); err != ErrInvalidPath {
+			t.Errorf("Apply(%s): got %v; expected %v", op, err, ErrInvalidPath)
+		}
+	}
+}
+
+func TestApply_reset(t *testing.T) {
+	nodes, _ := m.Nodes(m.S(m.M("p", m.T("a")), m.M("p", m.T("b"))))
+	ops := []Op{
+		{Type: Reset},
+		{Type: Insert, Path: []int{0}, Node: &m.Node{Type: m.TextNode, Data: "c"}},
+	}
+	applied, err := Apply(nodes, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := renderNodes(applied), "c"; got != expected {
+		t.Errorf("got %#v; expected %#v", got, expected)
+	}
+}
+
+func renderNodes(nodes []*m.Node) string {
+	elements := make([]m.Element, len(nodes))
+	for i, n := range nodes {
+		elements[i] = n
+	}
+	return m.RenderString(m.S(elements...))
+}
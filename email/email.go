@@ -0,0 +1,300 @@
+// Package email prepares elements for use as the HTML body of an email.
+//
+// Many email clients ignore style sheets, so the rules of the tree's style
+// elements are inlined into the style attributes of the elements they match:
+//
+//	body, err := email.Inline(page)
+//	if err != nil {
+//		return err
+//	}
+//	html := m.RenderString(body)
+//
+// Rules that cannot be inlined, such as those with pseudo-classes or sibling
+// combinators, are removed. @media rules are kept in a style element, since the clients that
+// support them can use them for responsive layouts.
+package email
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"layeh.com/m"
+	"layeh.com/m/internal/css"
+	selectorpkg "layeh.com/m/internal/selector"
+)
+
+// Render writes element to w with its styles inlined.
+//
+// A non-nil error is returned if the element could not be successfully written.
+func Render(w io.Writer, element m.Element) error {
+	el, err := Inline(element)
+	if err != nil {
+		return err
+	}
+	return m.Render(w, el)
+}
+
+// Inline returns the expanded tree of element with the rules of its style
+// elements inlined into style attributes.
+//
+// Declarations are applied in the order of the cascade: by specificity, then
+// by order in the document. Declarations of an existing style attribute take
+// precedence over the inlined rules, except for !important declarations.
+//
+// Constructs that email clients do not support are removed: style, script
+// and stylesheet link elements, event handler attributes, and rules with
+// pseudo-classes, pseudo-elements or sibling combinators (+ and ~). element
+// is not modified.
+//
+// A non-nil error is returned if element could not be expanded.
+func Inline(element m.Element) (m.Element, error) {
+	nodes, err := m.Nodes(element)
+	if err != nil {
+		return nil, err
+	}
+	var c collector
+	nodes = c.strip(nodes)
+	root := m.S(nodesToElements(nodes)...)
+
+	var rules []css.Rule
+	var media strings.Builder
+	for _, style := range c.Styles {
+		r, mediaRules := css.ParseStylesheet(style)
+		rules = append(rules, r...)
+		media.WriteString(mediaRules)
+	}
+
+	styles := map[*m.Node][]match{}
+	var matched []*m.Node
+	order := 0
+	for _, r := range rules {
+		for _, selector := range r.Selectors {
+			s, ok := specificity(selector)
+			if !ok {
+				continue
+			}
+			matches, err := m.FindAll(root, selector)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range matches {
+				if _, ok := styles[n]; !ok {
+					matched = append(matched, n)
+				}
+				styles[n] = append(styles[n], match{
+					Specificity:  s,
+					Order:        order,
+					Declarations: r.Declarations,
+				})
+			}
+			order++
+		}
+	}
+	for _, n := range matched {
+		setStyle(n, styles[n])
+	}
+
+	if media.Len() > 0 {
+		nodes = insertStyle(nodes, media.String())
+	}
+	return m.S(nodesToElements(nodes)...), nil
+}
+
+// match is a rule that matches an element.
+type match struct {
+	Specificity  [3]int
+	Order        int
+	Declarations []css.Declaration
+}
+
+// setStyle merges the declarations of matches into the style attribute of n.
+func setStyle(n *m.Node, matches []match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		for k := range a.Specificity {
+			if a.Specificity[k] != b.Specificity[k] {
+				return a.Specificity[k] < b.Specificity[k]
+			}
+		}
+		return a.Order < b.Order
+	})
+
+	var properties []string
+	values := map[string]css.Declaration{}
+	apply := func(declarations []css.Declaration) {
+		for _, d := range declarations {
+			existing, ok := values[d.Property]
+			if !ok {
+				properties = append(properties, d.Property)
+			} else if existing.Important && !d.Important {
+				continue
+			}
+			values[d.Property] = d
+		}
+	}
+	for _, match := range matches {
+		apply(match.Declarations)
+	}
+	style, hasStyle := n.Attr("style")
+	apply(css.ParseDeclarations(style))
+
+	var b strings.Builder
+	for _, property := range properties {
+		d := values[property]
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(d.Property)
+		b.WriteString(": ")
+		b.WriteString(d.Value)
+		if d.Important {
+			b.WriteString(" !important")
+		}
+	}
+	if !hasStyle {
+		n.Attributes = append(n.Attributes, m.Attribute{Key: "style", Value: b.String()})
+		return
+	}
+	for i := range n.Attributes {
+		if n.Attributes[i].Key == "style" {
+			n.Attributes[i].Value = b.String()
+		}
+	}
+}
+
+// specificity returns the specificity of selector, and false if it cannot
+// be inlined.
+func specificity(selector string) ([3]int, bool) {
+	var s [3]int
+	if unsupported(selector) {
+		return s, false
+	}
+	query, err := selectorpkg.ParseQuery(selector)
+	if err != nil || len(query) != 1 {
+		return s, false
+	}
+	for _, compound := range query[0] {
+		if compound.ID != "" {
+			s[0]++
+		}
+		s[1] += len(compound.Classes) + len(compound.Attributes)
+		if compound.TagName != "" && compound.TagName != "*" {
+			s[2]++
+		}
+	}
+	return s, true
+}
+
+// unsupported reports whether selector contains a pseudo-class, a
+// pseudo-element or a sibling combinator (+ or ~) outside of an attribute
+// selector.
+func unsupported(selector string) bool {
+	depth := 0
+	for i := 0; i < len(selector); i++ {
+		switch selector[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':', '+', '~':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collector copies a node tree without unsupported constructs, and collects
+// the contents of its style elements.
+type collector struct {
+	Styles []string
+}
+
+func (c *collector) strip(nodes []*m.Node) []*m.Node {
+	var stripped []*m.Node
+	for _, n := range nodes {
+		if n.Type != m.ElementNode {
+			stripped = append(stripped, n)
+			continue
+		}
+		if n.Namespace == m.HTMLNamespace {
+			switch strings.ToLower(n.TagName) {
+			case "style":
+				var b strings.Builder
+				for _, child := range n.Children {
+					b.WriteString(child.Data)
+				}
+				c.Styles = append(c.Styles, b.String())
+				continue
+			case "script":
+				continue
+			case "link":
+				if rel, _ := n.Attr("rel"); containsToken(rel, "stylesheet") {
+					continue
+				}
+			}
+		}
+		copied := *n
+		copied.Attributes = nil
+		for _, attr := range n.Attributes {
+			if !strings.HasPrefix(strings.ToLower(attr.Key), "on") {
+				copied.Attributes = append(copied.Attributes, attr)
+			}
+		}
+		copied.Children = c.strip(n.Children)
+		stripped = append(stripped, &copied)
+	}
+	return stripped
+}
+
+func containsToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertStyle adds a style element with css to the head element of nodes,
+// or before nodes if there is no head element.
+func insertStyle(nodes []*m.Node, css string) []*m.Node {
+	style := &m.Node{
+		Type:      m.ElementNode,
+		Namespace: m.HTMLNamespace,
+		TagName:   "style",
+		Children: []*m.Node{
+			{Type: m.RawNode, Data: css},
+		},
+	}
+	var insert func(nodes []*m.Node) bool
+	insert = func(nodes []*m.Node) bool {
+		for _, n := range nodes {
+			if n.Type != m.ElementNode {
+				continue
+			}
+			if n.Namespace == m.HTMLNamespace && strings.EqualFold(n.TagName, "head") {
+				n.Children = append(n.Children, style)
+				return true
+			}
+			if insert(n.Children) {
+				return true
+			}
+		}
+		return false
+	}
+	if insert(nodes) {
+		return nodes
+	}
+	return append([]*m.Node{style}, nodes...)
+}
+
+func nodesToElements(nodes []*m.Node) []m.Element {
+	elements := make([]m.Element, len(nodes))
+	for i, n := range nodes {
+		elements[i] = n
+	}
+	return elements
+}
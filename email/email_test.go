@@ -0,0 +1,126 @@
+package email
+
+import (
+	"testing"
+
+	"layeh.com/m"
+)
+
+func TestInline(t *testing.T) {
+	tbl := []struct {
+		Element  m.Element
+		Expected string
+	}{
+		{
+			m.S(
+				m.M("style", m.Raw(`p { color: red; margin: 0 } .note { color: blue }`)),
+				m.M("p", m.T("A")),
+				m.M("p.note", m.T("B")),
+			),
+			`<p style="color: red; margin: 0">A</p><p class="note" style="color: blue; margin: 0">B</p>`,
+		},
+		{
+			// Specificity wins over order.
+			m.S(
+				m.M("style", m.Raw(`#a { color: red } div p { color: green } p { color: blue }`)),
+				m.M("div", m.M("p#a", m.T("A")), m.M("p", m.T("B"))),
+			),
+			`<div><p id="a" style="color: red">A</p><p style="color: green">B</p></div>`,
+		},
+		{
+			// Inline styles win, except over !important.
+			m.S(
+				m.M("style", m.Raw(`p { color: red; font-weight: bold !important }`)),
+				m.M("p[style=color: blue; font-weight: normal]", m.T("A")),
+			),
+			`<p style="color: blue; font-weight: bold !important">A</p>`,
+		},
+		{
+			// Unsupported rules and elements are removed.
+			m.S(
+				m.M("style", m.Raw(`/* comment */ @import url("x.css"); @font-face { font-family: x } a:hover { color: red } a, b { color: green }`)),
+				m.M("script", m.Raw("alert(1)")),
+				m.M("link[rel=stylesheet][href=x.css]"),
+				m.M("a[href=/][onclick=alert(1)]", m.T("A")),
+			),
+			`<a href="/" style="color: green">A</a>`,
+		},
+		{
+			// Rules with sibling combinators are removed.
+			m.S(
+				m.M("style", m.Raw(`h1 + p, h1 ~ p, h1~p { color: red } p[class~=a] { color: green }`)),
+				m.M("h1", m.T("A")),
+				m.M("p.a", m.T("B")),
+			),
+			`<h1>A</h1><p class="a" style="color: green">B</p>`,
+		},
+		{
+			// @media rules are kept in the head.
+			m.Document(
+				m.M("html",
+					m.M("head", m.M("style", m.Raw(`td { padding: 0 } @media (max-width: 600px) { td { display: block } }`))),
+					m.M("body", m.M("table", m.M("tr", m.M("td", m.T("A"))))),
+				),
+			),
+			"<!DOCTYPE html>\n<html><head><style>@media (max-width: 600px) { td { display: block } }\n</style></head><body><table><tr><td style=\"padding: 0\">A</td></tr></table></body></html>",
+		},
+		{
+			m.S(
+				m.M("style", m.Raw(`div { background: url("data:image/png;base64,AA==") }`)),
+				m.M("div"),
+			),
+			`<div style="background: url(&#34;data:image/png;base64,AA==&#34;)"></div>`,
+		},
+	}
+
+	for i, tt := range tbl {
+		el, err := Inline(tt.Element)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if actual := m.RenderString(el); actual != tt.Expected {
+			t.Errorf("%d: expected %q, got %q", i, tt.Expected, actual)
+		}
+	}
+}
+
+func TestInline_unmodified(t *testing.T) {
+	nodes, err := m.Nodes(m.S(
+		m.M("style", m.Raw(`p { color: red }`)),
+		m.M("p"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := nodes[1]
+	if _, err := Inline(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Inline(m.S(nodes[0], nodes[1])); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Attributes) != 0 {
+		t.Fatalf("element was modified: %v", p.Attributes)
+	}
+}
+
+func Test_specificity(t *testing.T) {
+	tests := []struct {
+		Selector    string
+		Specificity [3]int
+		OK          bool
+	}{
+		{"p", [3]int{0, 0, 1}, true},
+		{"#a .b[c~=d] > p", [3]int{1, 2, 1}, true},
+		{"a:hover", [3]int{}, false},
+		{"h1 + p", [3]int{}, false},
+		{"h1 ~ p", [3]int{}, false},
+		{"h1~p", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		s, ok := specificity(tt.Selector)
+		if s != tt.Specificity || ok != tt.OK {
+			t.Errorf("specificity(%q) = %v, %t; expected %v, %t", tt.Selector, s, ok, tt.Specificity, tt.OK)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package m_test
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	. "layeh.com/m"
@@ -19,6 +20,45 @@ func ExampleRender() {
 	// <p>Hello World</p>
 }
 
+func ExampleRenderText() {
+	el := S(
+		M("p", T("Your order has shipped.")),
+		M("ul",
+			M("li", T("1 × Widget")),
+			M("li", T("2 × Gadget")),
+		),
+		M("p", M("a[href=https://example.com/orders/1]", T("Track your order"))),
+	)
+	if err := RenderText(os.Stdout, el); err != nil {
+		panic(err)
+	}
+	// Output:
+	// Your order has shipped.
+	//
+	// - 1 × Widget
+	// - 2 × Gadget
+	//
+	// Track your order (https://example.com/orders/1)
+}
+
+func ExampleRenderer() {
+	r := Renderer{
+		XML:            true,
+		XMLDeclaration: true,
+	}
+	el := M("p",
+		T("Line 1"),
+		M("br"),
+		T("Line 2"),
+	)
+	if err := r.Render(os.Stdout, el); err != nil {
+		panic(err)
+	}
+	// Output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <p xmlns="http://www.w3.org/1999/xhtml">Line 1<br/>Line 2</p>
+}
+
 func ExampleRenderString() {
 	el := M("p",
 		T("Hello World"),
@@ -61,6 +101,18 @@ func ExampleDocument() {
 	// <head><title>Hello World</title></head><body></body>
 }
 
+func ExampleEach() {
+	users := []string{"Alice", "Bob"}
+	el := M("ul",
+		Each(users, func(user string, i int) Element {
+			return M("li", F("%d. %s", i+1, user))
+		}),
+	)
+	fmt.Println(RenderString(el))
+	// Output:
+	// <ul><li>1. Alice</li><li>2. Bob</li></ul>
+}
+
 func ExampleF() {
 	el := M("p",
 		F("Hello, %s", "World"),
@@ -126,6 +178,53 @@ func ExampleIfElse() {
 	// <p>0!</p><p>1?</p><p>2!</p><p>3?</p><p>4!</p>
 }
 
+func ExampleMap() {
+	prices := map[string]int{
+		"Tea":    3,
+		"Coffee": 4,
+	}
+	el := M("dl",
+		Map(prices, func(name string, price int) Element {
+			return S(M("dt", T(name)), M("dd", F("$%d", price)))
+		}),
+	)
+	fmt.Println(RenderString(el))
+	// Output:
+	// <dl><dt>Coffee</dt><dd>$4</dd><dt>Tea</dt><dd>$3</dd></dl>
+}
+
+func ExampleKeyed() {
+	type user struct {
+		Name string
+		Age  int
+	}
+	users := []user{{"Carol", 41}, {"Alice", 29}, {"Bob", 35}}
+	el := M("ol",
+		Keyed(users, func(u user) string { return u.Name }, func(u user, _ int) Element {
+			return M("li", F("%s (%d)", u.Name, u.Age))
+		}),
+	)
+	fmt.Println(RenderString(el))
+	// Output:
+	// <ol><li>Alice (29)</li><li>Bob (35)</li><li>Carol (41)</li></ol>
+}
+
+func ExampleSwitch() {
+	status := func(code int) Element {
+		return Switch(code,
+			Case(200, func() Element { return T("OK") }),
+			Case(404, func() Element { return T("Not Found") }),
+			Default(func() Element { return F("Error %d", code) }),
+		)
+	}
+	el := Each([]int{200, 404, 500}, func(code int, _ int) Element {
+		return M("p", status(code))
+	})
+	fmt.Println(RenderString(el))
+	// Output:
+	// <p>OK</p><p>Not Found</p><p>Error 500</p>
+}
+
 func ExampleM() {
 	el := M("h1#headline.active.etc[data-id=3]",
 		T("Hello World"),
@@ -134,3 +233,40 @@ func ExampleM() {
 	// Output:
 	// <h1 id="headline" class="active etc" data-id="3">Hello World</h1>
 }
+
+func ExampleSeq2() {
+	el := M("ol",
+		Seq2(slices.Backward([]string{"a", "b", "c"}), func(i int, s string) Element {
+			return M("li", F("%d: %s", i, s))
+		}),
+	)
+	fmt.Println(RenderString(el))
+	// Output:
+	// <ol><li>2: c</li><li>1: b</li><li>0: a</li></ol>
+}
+
+func ExampleFind() {
+	el := M("ul",
+		M("li", T("Home")),
+		M("li.active", T("About")),
+	)
+	n, err := Find(el, "ul > li.active")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(n.Text())
+	// Output:
+	// About
+}
+
+func ExampleSVG() {
+	el := M("p",
+		SVG("svg[width=16][height=16][viewBox=0 0 16 16]",
+			SVG("linearGradient#fade", SVG("stop[offset=0][stop-color=red]")),
+			SVG("circle[cx=8][cy=8][r=8][fill=url(#fade)]"),
+		),
+	)
+	fmt.Println(RenderString(el))
+	// Output:
+	// <p><svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 16 16"><linearGradient id="fade"><stop offset="0" stop-color="red"/></linearGradient><circle cx="8" cy="8" r="8" fill="url(#fade)"/></svg></p>
+}
@@ -0,0 +1,313 @@
+// Package feed builds Atom feeds, RSS 2.0 feeds and sitemaps as m elements.
+//
+// The returned elements must be rendered as XML, which Render does:
+//
+//	w.Header().Set("Content-Type", feed.AtomContentType)
+//	feed.Render(w, feed.Atom(f))
+//
+// Entry content is an m.Element, so the same components that render a
+// page can render its feed.
+package feed
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"layeh.com/m"
+)
+
+// Content types of the documents.
+const (
+	AtomContentType    = "application/atom+xml; charset=utf-8"
+	RSSContentType     = "application/rss+xml; charset=utf-8"
+	SitemapContentType = "application/xml; charset=utf-8"
+)
+
+// Namespaces of the documents.
+const (
+	AtomNamespace    = "http://www.w3.org/2005/Atom"
+	SitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// ErrNoUpdated is returned when an Atom feed is rendered without the time
+// it or one of its entries was updated, which Atom requires.
+var ErrNoUpdated = errors.New("feed: updated time is required by Atom")
+
+// Render writes element to w as an XML document.
+func Render(w io.Writer, element m.Element) error {
+	r := m.Renderer{
+		XML:            true,
+		XMLDeclaration: true,
+	}
+	return r.Render(w, element)
+}
+
+// Person is the author of a feed or entry.
+type Person struct {
+	Name  string
+	Email string
+	URI   string
+}
+
+// Enclosure is a file attached to an entry, such as a podcast episode.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// Entry is an entry of a feed.
+type Entry struct {
+	// ID uniquely and permanently identifies the entry. Link is used if
+	// ID is empty.
+	ID    string
+	Title string
+	Link  string
+
+	Published time.Time
+	// Updated is the time the entry was last modified. Published is used
+	// if Updated is zero. Atom feeds require one of them.
+	Updated time.Time
+
+	Author *Person
+
+	// Summary is a plain text summary of the entry.
+	Summary string
+	// Content is the content of the entry.
+	//
+	// Atom feeds contain it as XHTML, in which raw elements are written
+	// unchanged, so they must be well-formed XML. Raw HTML, such as HTML
+	// blocks converted by markdown.Convert or entities like &nbsp;, can make
+	// the feed invalid.
+	Content m.Element
+
+	Categories []string
+	Enclosures []Enclosure
+}
+
+// Feed is an Atom or RSS feed.
+type Feed struct {
+	// ID uniquely and permanently identifies the feed. Link is used if ID
+	// is empty.
+	ID       string
+	Title    string
+	Subtitle string
+	// Link is the URL of the website of the feed, and Self is the URL of
+	// the feed itself.
+	Link string
+	Self string
+
+	// Updated is the time the feed was last modified. The latest update of
+	// the entries is used if Updated is zero. If neither is set, rendering
+	// an Atom feed returns ErrNoUpdated.
+	Updated time.Time
+
+	Author   *Person
+	Language string
+
+	Entries []*Entry
+}
+
+func (f *Feed) id() string {
+	if f.ID != "" {
+		return f.ID
+	}
+	return f.Link
+}
+
+func (f *Feed) updated() time.Time {
+	if !f.Updated.IsZero() {
+		return f.Updated
+	}
+	var updated time.Time
+	for _, e := range f.Entries {
+		if u := e.updated(); u.After(updated) {
+			updated = u
+		}
+	}
+	return updated
+}
+
+func (e *Entry) id() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return e.Link
+}
+
+func (e *Entry) updated() time.Time {
+	if !e.Updated.IsZero() {
+		return e.Updated
+	}
+	return e.Published
+}
+
+// Atom returns f as an Atom feed document.
+//
+// Rendering it returns ErrNoUpdated if the feed or one of its entries has
+// no updated time.
+func Atom(f *Feed) m.Element {
+	return atom("feed",
+		m.If(f.Language != "", m.Attr("xml:lang", f.Language)),
+		atomText("id", f.id()),
+		atomText("title", f.Title),
+		m.If(f.Subtitle != "", atomText("subtitle", f.Subtitle)),
+		atomUpdated(f.updated()),
+		m.If(f.Link != "", atom("link[rel=alternate]", m.Attr("href", f.Link))),
+		m.If(f.Self != "", atom("link[rel=self]", m.Attr("href", f.Self))),
+		atomPerson("author", f.Author),
+		m.Range(len(f.Entries), func(i int) m.Element {
+			return atomEntry(f.Entries[i])
+		}),
+	)
+}
+
+func atomEntry(e *Entry) m.Element {
+	return atom("entry",
+		atomText("id", e.id()),
+		atomText("title", e.Title),
+		m.If(e.Link != "", atom("link[rel=alternate]", m.Attr("href", e.Link))),
+		m.If(!e.Published.IsZero(), atomDate("published", e.Published)),
+		atomUpdated(e.updated()),
+		atomPerson("author", e.Author),
+		m.Range(len(e.Categories), func(i int) m.Element {
+			return atom("category", m.Attr("term", e.Categories[i]))
+		}),
+		m.Range(len(e.Enclosures), func(i int) m.Element {
+			enclosure := e.Enclosures[i]
+			return atom("link[rel=enclosure]",
+				m.Attr("href", enclosure.URL),
+				m.If(enclosure.Type != "", m.Attr("type", enclosure.Type)),
+				m.If(enclosure.Length > 0, m.Attr("length", strconv.FormatInt(enclosure.Length, 10))),
+			)
+		}),
+		m.If(e.Summary != "", atomText("summary", e.Summary)),
+		m.If(e.Content != nil, atom("content[type=xhtml]",
+			m.M("div", e.Content),
+		)),
+	)
+}
+
+func atom(selector string, elements ...m.Element) m.Element {
+	return m.NS(AtomNamespace, selector, elements...)
+}
+
+func atomText(tagName, text string) m.Element {
+	return atom(tagName, m.T(text))
+}
+
+func atomDate(tagName string, t time.Time) m.Element {
+	return atomText(tagName, t.Format(time.RFC3339))
+}
+
+// atomUpdated returns the updated element of t, or an element that fails to
+// render with ErrNoUpdated if t is zero.
+func atomUpdated(t time.Time) m.Element {
+	if t.IsZero() {
+		return m.LazyErr(func() (m.Element, error) {
+			return nil, ErrNoUpdated
+		})
+	}
+	return atomDate("updated", t)
+}
+
+func atomPerson(tagName string, p *Person) m.Element {
+	if p == nil {
+		return nil
+	}
+	return atom(tagName,
+		atomText("name", p.Name),
+		m.If(p.Email != "", atomText("email", p.Email)),
+		m.If(p.URI != "", atomText("uri", p.URI)),
+	)
+}
+
+// RSS returns f as an RSS 2.0 document.
+//
+// Entry content is rendered as HTML into the entry's description. Authors
+// are only included if they have an email address, as RSS requires.
+func RSS(f *Feed) m.Element {
+	return rss("rss[version=2.0]",
+		m.If(f.Self != "", m.Attr("xmlns:atom", AtomNamespace)),
+		rss("channel",
+			rssText("title", f.Title),
+			rssText("link", f.Link),
+			rssText("description", f.Subtitle),
+			m.If(f.Self != "", rss("atom:link[rel=self][type=application/rss+xml]", m.Attr("href", f.Self))),
+			m.If(f.Language != "", rssText("language", f.Language)),
+			m.If(!f.updated().IsZero(), rssDate("lastBuildDate", f.updated())),
+			rssPerson("managingEditor", f.Author),
+			m.Range(len(f.Entries), func(i int) m.Element {
+				return rssItem(f.Entries[i])
+			}),
+		),
+	)
+}
+
+func rssItem(e *Entry) m.Element {
+	var description m.Element
+	if e.Content != nil {
+		description = rss("description", rssHTML(e.Content))
+	} else if e.Summary != "" {
+		description = rssText("description", e.Summary)
+	}
+	return rss("item",
+		rssText("title", e.Title),
+		m.If(e.Link != "", rssText("link", e.Link)),
+		description,
+		rssPerson("author", e.Author),
+		m.Range(len(e.Categories), func(i int) m.Element {
+			return rssText("category", e.Categories[i])
+		}),
+		m.Range(len(e.Enclosures), func(i int) m.Element {
+			enclosure := e.Enclosures[i]
+			return rss("enclosure",
+				m.Attr("url", enclosure.URL),
+				m.Attr("length", strconv.FormatInt(enclosure.Length, 10)),
+				m.Attr("type", enclosure.Type),
+			)
+		}),
+		m.If(e.id() != "", rss("guid",
+			m.If(e.id() != e.Link, m.Attr("isPermaLink", "false")),
+			m.T(e.id()),
+		)),
+		m.If(!e.Published.IsZero(), rssDate("pubDate", e.Published)),
+	)
+}
+
+func rss(selector string, elements ...m.Element) m.Element {
+	return m.NS("", selector, elements...)
+}
+
+// rssHTML returns the HTML of content as text. content is rendered when the
+// feed is, so that its errors are returned by Render as they are for Atom.
+func rssHTML(content m.Element) m.Element {
+	return m.LazyErr(func() (m.Element, error) {
+		var b strings.Builder
+		if err := m.Render(&b, content); err != nil {
+			return nil, err
+		}
+		return m.T(b.String()), nil
+	})
+}
+
+func rssText(tagName, text string) m.Element {
+	return rss(tagName, m.T(text))
+}
+
+func rssDate(tagName string, t time.Time) m.Element {
+	return rssText(tagName, t.Format(time.RFC1123Z))
+}
+
+func rssPerson(tagName string, p *Person) m.Element {
+	if p == nil || p.Email == "" {
+		return nil
+	}
+	if p.Name == "" {
+		return rssText(tagName, p.Email)
+	}
+	return rssText(tagName, p.Email+" ("+p.Name+")")
+}
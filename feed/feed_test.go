@@ -0,0 +1,186 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"layeh.com/m"
+)
+
+var testFeed = &Feed{
+	Title:    "Example Blog",
+	Subtitle: "Notes & news",
+	Link:     "https://example.com/",
+	Self:     "https://example.com/feed",
+	Language: "en",
+	Author:   &Person{Name: "Alice", Email: "alice@example.com"},
+	Entries: []*Entry{
+		{
+			Title:      "Hello <World>",
+			Link:       "https://example.com/hello",
+			Published:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Updated:    time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC),
+			Content:    m.S(m.M("p", m.T("Hi!")), m.M("br")),
+			Categories: []string{"go"},
+			Enclosures: []Enclosure{
+				{URL: "https://example.com/hello.mp3", Type: "audio/mpeg", Length: 1234},
+			},
+		},
+		{
+			ID:        "urn:uuid:1",
+			Title:     "Second",
+			Summary:   "Plain text",
+			Published: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+func render(t *testing.T, el m.Element) string {
+	t.Helper()
+	var b strings.Builder
+	if err := Render(&b, el); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check that the document is well-formed.
+	d := xml.NewDecoder(strings.NewReader(b.String()))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid XML: %s\n%s", err, b.String())
+		}
+	}
+	return b.String()
+}
+
+func TestAtom(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">` +
+		`<id>https://example.com/</id>` +
+		`<title>Example Blog</title>` +
+		`<subtitle>Notes &amp; news</subtitle>` +
+		`<updated>2020-02-03T04:05:06Z</updated>` +
+		`<link rel="alternate" href="https://example.com/"/>` +
+		`<link rel="self" href="https://example.com/feed"/>` +
+		`<author><name>Alice</name><email>alice@example.com</email></author>` +
+		`<entry>` +
+		`<id>https://example.com/hello</id>` +
+		`<title>Hello &lt;World&gt;</title>` +
+		`<link rel="alternate" href="https://example.com/hello"/>` +
+		`<published>2020-01-02T03:04:05Z</published>` +
+		`<updated>2020-02-03T04:05:06Z</updated>` +
+		`<category term="go"/>` +
+		`<link rel="enclosure" href="https://example.com/hello.mp3" type="audio/mpeg" length="1234"/>` +
+		`<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hi!</p><br/></div></content>` +
+		`</entry>` +
+		`<entry>` +
+		`<id>urn:uuid:1</id>` +
+		`<title>Second</title>` +
+		`<published>2020-01-01T00:00:00Z</published>` +
+		`<updated>2020-01-01T00:00:00Z</updated>` +
+		`<summary>Plain text</summary>` +
+		`</entry>` +
+		`</feed>`
+	if output := render(t, Atom(testFeed)); output != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>` +
+		`<title>Example Blog</title>` +
+		`<link>https://example.com/</link>` +
+		`<description>Notes &amp; news</description>` +
+		`<atom:link rel="self" type="application/rss+xml" href="https://example.com/feed"/>` +
+		`<language>en</language>` +
+		`<lastBuildDate>Mon, 03 Feb 2020 04:05:06 +0000</lastBuildDate>` +
+		`<managingEditor>alice@example.com (Alice)</managingEditor>` +
+		`<item>` +
+		`<title>Hello &lt;World&gt;</title>` +
+		`<link>https://example.com/hello</link>` +
+		`<description>&lt;p&gt;Hi!&lt;/p&gt;&lt;br&gt;</description>` +
+		`<category>go</category>` +
+		`<enclosure url="https://example.com/hello.mp3" length="1234" type="audio/mpeg"/>` +
+		`<guid>https://example.com/hello</guid>` +
+		`<pubDate>Thu, 02 Jan 2020 03:04:05 +0000</pubDate>` +
+		`</item>` +
+		`<item>` +
+		`<title>Second</title>` +
+		`<description>Plain text</description>` +
+		`<guid isPermaLink="false">urn:uuid:1</guid>` +
+		`<pubDate>Wed, 01 Jan 2020 00:00:00 +0000</pubDate>` +
+		`</item>` +
+		`</channel></rss>`
+	if output := render(t, RSS(testFeed)); output != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	el := Sitemap(
+		URL{Loc: "https://example.com/?a=1&b=2"},
+		URL{
+			Loc:        "https://example.com/about",
+			LastMod:    time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			ChangeFreq: Monthly,
+			Priority:   0.8,
+		},
+	)
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		`<url><loc>https://example.com/?a=1&amp;b=2</loc></url>` +
+		`<url><loc>https://example.com/about</loc><lastmod>2020-01-02T00:00:00Z</lastmod><changefreq>monthly</changefreq><priority>0.8</priority></url>` +
+		`</urlset>`
+	if output := render(t, el); output != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestRender_contentError(t *testing.T) {
+	errNotFound := errors.New("not found")
+	f := &Feed{
+		Title: "Example Blog",
+		Link:  "https://example.com/",
+		Entries: []*Entry{
+			{
+				Title:     "Hello",
+				Link:      "https://example.com/hello",
+				Published: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Content: m.M("p", m.LazyErr(func() (m.Element, error) {
+					return nil, errNotFound
+				})),
+			},
+		},
+	}
+	for _, el := range []m.Element{Atom(f), RSS(f)} {
+		if err := Render(io.Discard, el); !errors.Is(err, errNotFound) {
+			t.Errorf("got error %v; expected %v", err, errNotFound)
+		}
+	}
+}
+
+func TestAtom_noUpdated(t *testing.T) {
+	tests := []*Feed{
+		{Title: "Example Blog"},
+		{
+			Title:   "Example Blog",
+			Updated: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			Entries: []*Entry{{Title: "Hello"}},
+		},
+	}
+	for _, f := range tests {
+		if err := Render(io.Discard, Atom(f)); !errors.Is(err, ErrNoUpdated) {
+			t.Errorf("got error %v; expected %v", err, ErrNoUpdated)
+		}
+		// RSS does not require dates.
+		if err := Render(io.Discard, RSS(f)); err != nil {
+			t.Errorf("RSS: got error %v", err)
+		}
+	}
+}
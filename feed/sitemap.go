@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"strconv"
+	"time"
+
+	"layeh.com/m"
+)
+
+// ChangeFreq is how frequently a page is likely to change.
+type ChangeFreq string
+
+// Change frequencies.
+const (
+	Always  ChangeFreq = "always"
+	Hourly  ChangeFreq = "hourly"
+	Daily   ChangeFreq = "daily"
+	Weekly  ChangeFreq = "weekly"
+	Monthly ChangeFreq = "monthly"
+	Yearly  ChangeFreq = "yearly"
+	Never   ChangeFreq = "never"
+)
+
+// URL is a page of a sitemap. Zero values of the optional fields are
+// omitted.
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq ChangeFreq
+	// Priority is the priority of the page relative to the other pages of
+	// the site, from 0.0 to 1.0.
+	Priority float64
+}
+
+// Sitemap returns a sitemap document of urls.
+func Sitemap(urls ...URL) m.Element {
+	return sitemap("urlset",
+		m.Range(len(urls), func(i int) m.Element {
+			u := urls[i]
+			return sitemap("url",
+				sitemap("loc", m.T(u.Loc)),
+				m.If(!u.LastMod.IsZero(), sitemap("lastmod", m.T(u.LastMod.Format(time.RFC3339)))),
+				m.If(u.ChangeFreq != "", sitemap("changefreq", m.T(string(u.ChangeFreq)))),
+				m.If(u.Priority != 0, sitemap("priority", m.T(strconv.FormatFloat(u.Priority, 'f', 1, 64)))),
+			)
+		}),
+	)
+}
+
+func sitemap(selector string, elements ...m.Element) m.Element {
+	return m.NS(SitemapNamespace, selector, elements...)
+}
@@ -1,3 +1,3 @@
 module layeh.com/m
 
-go 1.13
+go 1.23.0
@@ -0,0 +1,652 @@
+// Code generated by layeh.com/m/h/gen; DO NOT EDIT.
+
+package h
+
+import (
+	"layeh.com/m"
+)
+
+// AbbrAttr returns an abbr attribute with the given value.
+func AbbrAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "abbr", Value: value}
+}
+
+// Accept returns an accept attribute with the given value.
+func Accept(value string) *m.Attribute {
+	return &m.Attribute{Key: "accept", Value: value}
+}
+
+// AcceptCharset returns an accept-charset attribute with the given value.
+func AcceptCharset(value string) *m.Attribute {
+	return &m.Attribute{Key: "accept-charset", Value: value}
+}
+
+// Accesskey returns an accesskey attribute with the given value.
+func Accesskey(value string) *m.Attribute {
+	return &m.Attribute{Key: "accesskey", Value: value}
+}
+
+// Action returns an action attribute with the given value.
+func Action(value string) *m.Attribute {
+	return &m.Attribute{Key: "action", Value: value}
+}
+
+// Allow returns an allow attribute with the given value.
+func Allow(value string) *m.Attribute {
+	return &m.Attribute{Key: "allow", Value: value}
+}
+
+// Allowfullscreen returns an allowfullscreen attribute, which is a boolean attribute.
+func Allowfullscreen() *m.Attribute {
+	return &m.Attribute{Key: "allowfullscreen"}
+}
+
+// Alt returns an alt attribute with the given value.
+func Alt(value string) *m.Attribute {
+	return &m.Attribute{Key: "alt", Value: value}
+}
+
+// As returns an as attribute with the given value.
+func As(value string) *m.Attribute {
+	return &m.Attribute{Key: "as", Value: value}
+}
+
+// Async returns an async attribute, which is a boolean attribute.
+func Async() *m.Attribute {
+	return &m.Attribute{Key: "async"}
+}
+
+// Autocapitalize returns an autocapitalize attribute with the given value.
+func Autocapitalize(value string) *m.Attribute {
+	return &m.Attribute{Key: "autocapitalize", Value: value}
+}
+
+// Autocomplete returns an autocomplete attribute with the given value.
+func Autocomplete(value string) *m.Attribute {
+	return &m.Attribute{Key: "autocomplete", Value: value}
+}
+
+// Autofocus returns an autofocus attribute, which is a boolean attribute.
+func Autofocus() *m.Attribute {
+	return &m.Attribute{Key: "autofocus"}
+}
+
+// Autoplay returns an autoplay attribute, which is a boolean attribute.
+func Autoplay() *m.Attribute {
+	return &m.Attribute{Key: "autoplay"}
+}
+
+// Blocking returns a blocking attribute with the given value.
+func Blocking(value string) *m.Attribute {
+	return &m.Attribute{Key: "blocking", Value: value}
+}
+
+// Charset returns a charset attribute with the given value.
+func Charset(value string) *m.Attribute {
+	return &m.Attribute{Key: "charset", Value: value}
+}
+
+// Checked returns a checked attribute, which is a boolean attribute.
+func Checked() *m.Attribute {
+	return &m.Attribute{Key: "checked"}
+}
+
+// CiteAttr returns a cite attribute with the given value.
+func CiteAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "cite", Value: value}
+}
+
+// Class returns a class attribute with the given value.
+func Class(value string) *m.Attribute {
+	return &m.Attribute{Key: "class", Value: value}
+}
+
+// Color returns a color attribute with the given value.
+func Color(value string) *m.Attribute {
+	return &m.Attribute{Key: "color", Value: value}
+}
+
+// Cols returns a cols attribute with the given value.
+func Cols(value string) *m.Attribute {
+	return &m.Attribute{Key: "cols", Value: value}
+}
+
+// Colspan returns a colspan attribute with the given value.
+func Colspan(value string) *m.Attribute {
+	return &m.Attribute{Key: "colspan", Value: value}
+}
+
+// Content returns a content attribute with the given value.
+func Content(value string) *m.Attribute {
+	return &m.Attribute{Key: "content", Value: value}
+}
+
+// Contenteditable returns a contenteditable attribute with the given value.
+func Contenteditable(value string) *m.Attribute {
+	return &m.Attribute{Key: "contenteditable", Value: value}
+}
+
+// Controls returns a controls attribute, which is a boolean attribute.
+func Controls() *m.Attribute {
+	return &m.Attribute{Key: "controls"}
+}
+
+// Coords returns a coords attribute with the given value.
+func Coords(value string) *m.Attribute {
+	return &m.Attribute{Key: "coords", Value: value}
+}
+
+// Crossorigin returns a crossorigin attribute with the given value.
+func Crossorigin(value string) *m.Attribute {
+	return &m.Attribute{Key: "crossorigin", Value: value}
+}
+
+// DataAttr returns a data attribute with the given value.
+func DataAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "data", Value: value}
+}
+
+// Datetime returns a datetime attribute with the given value.
+func Datetime(value string) *m.Attribute {
+	return &m.Attribute{Key: "datetime", Value: value}
+}
+
+// Decoding returns a decoding attribute with the given value.
+func Decoding(value string) *m.Attribute {
+	return &m.Attribute{Key: "decoding", Value: value}
+}
+
+// Default returns a default attribute, which is a boolean attribute.
+func Default() *m.Attribute {
+	return &m.Attribute{Key: "default"}
+}
+
+// Defer returns a defer attribute, which is a boolean attribute.
+func Defer() *m.Attribute {
+	return &m.Attribute{Key: "defer"}
+}
+
+// Dir returns a dir attribute with the given value.
+func Dir(value string) *m.Attribute {
+	return &m.Attribute{Key: "dir", Value: value}
+}
+
+// Dirname returns a dirname attribute with the given value.
+func Dirname(value string) *m.Attribute {
+	return &m.Attribute{Key: "dirname", Value: value}
+}
+
+// Disabled returns a disabled attribute, which is a boolean attribute.
+func Disabled() *m.Attribute {
+	return &m.Attribute{Key: "disabled"}
+}
+
+// Download returns a download attribute with the given value.
+func Download(value string) *m.Attribute {
+	return &m.Attribute{Key: "download", Value: value}
+}
+
+// Draggable returns a draggable attribute with the given value.
+func Draggable(value string) *m.Attribute {
+	return &m.Attribute{Key: "draggable", Value: value}
+}
+
+// Enctype returns an enctype attribute with the given value.
+func Enctype(value string) *m.Attribute {
+	return &m.Attribute{Key: "enctype", Value: value}
+}
+
+// Enterkeyhint returns an enterkeyhint attribute with the given value.
+func Enterkeyhint(value string) *m.Attribute {
+	return &m.Attribute{Key: "enterkeyhint", Value: value}
+}
+
+// Fetchpriority returns a fetchpriority attribute with the given value.
+func Fetchpriority(value string) *m.Attribute {
+	return &m.Attribute{Key: "fetchpriority", Value: value}
+}
+
+// For returns a for attribute with the given value.
+func For(value string) *m.Attribute {
+	return &m.Attribute{Key: "for", Value: value}
+}
+
+// FormAttr returns a form attribute with the given value.
+func FormAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "form", Value: value}
+}
+
+// Formaction returns a formaction attribute with the given value.
+func Formaction(value string) *m.Attribute {
+	return &m.Attribute{Key: "formaction", Value: value}
+}
+
+// Formenctype returns a formenctype attribute with the given value.
+func Formenctype(value string) *m.Attribute {
+	return &m.Attribute{Key: "formenctype", Value: value}
+}
+
+// Formmethod returns a formmethod attribute with the given value.
+func Formmethod(value string) *m.Attribute {
+	return &m.Attribute{Key: "formmethod", Value: value}
+}
+
+// Formnovalidate returns a formnovalidate attribute, which is a boolean attribute.
+func Formnovalidate() *m.Attribute {
+	return &m.Attribute{Key: "formnovalidate"}
+}
+
+// Formtarget returns a formtarget attribute with the given value.
+func Formtarget(value string) *m.Attribute {
+	return &m.Attribute{Key: "formtarget", Value: value}
+}
+
+// Headers returns a headers attribute with the given value.
+func Headers(value string) *m.Attribute {
+	return &m.Attribute{Key: "headers", Value: value}
+}
+
+// Height returns a height attribute with the given value.
+func Height(value string) *m.Attribute {
+	return &m.Attribute{Key: "height", Value: value}
+}
+
+// Hidden returns a hidden attribute with the given value.
+func Hidden(value string) *m.Attribute {
+	return &m.Attribute{Key: "hidden", Value: value}
+}
+
+// High returns a high attribute with the given value.
+func High(value string) *m.Attribute {
+	return &m.Attribute{Key: "high", Value: value}
+}
+
+// Href returns an href attribute with the given value.
+func Href(value string) *m.Attribute {
+	return &m.Attribute{Key: "href", Value: value}
+}
+
+// Hreflang returns an hreflang attribute with the given value.
+func Hreflang(value string) *m.Attribute {
+	return &m.Attribute{Key: "hreflang", Value: value}
+}
+
+// HttpEquiv returns an http-equiv attribute with the given value.
+func HttpEquiv(value string) *m.Attribute {
+	return &m.Attribute{Key: "http-equiv", Value: value}
+}
+
+// Id returns an id attribute with the given value.
+func Id(value string) *m.Attribute {
+	return &m.Attribute{Key: "id", Value: value}
+}
+
+// Imagesizes returns an imagesizes attribute with the given value.
+func Imagesizes(value string) *m.Attribute {
+	return &m.Attribute{Key: "imagesizes", Value: value}
+}
+
+// Imagesrcset returns an imagesrcset attribute with the given value.
+func Imagesrcset(value string) *m.Attribute {
+	return &m.Attribute{Key: "imagesrcset", Value: value}
+}
+
+// Inert returns an inert attribute, which is a boolean attribute.
+func Inert() *m.Attribute {
+	return &m.Attribute{Key: "inert"}
+}
+
+// Inputmode returns an inputmode attribute with the given value.
+func Inputmode(value string) *m.Attribute {
+	return &m.Attribute{Key: "inputmode", Value: value}
+}
+
+// Integrity returns an integrity attribute with the given value.
+func Integrity(value string) *m.Attribute {
+	return &m.Attribute{Key: "integrity", Value: value}
+}
+
+// Is returns an is attribute with the given value.
+func Is(value string) *m.Attribute {
+	return &m.Attribute{Key: "is", Value: value}
+}
+
+// Ismap returns an ismap attribute, which is a boolean attribute.
+func Ismap() *m.Attribute {
+	return &m.Attribute{Key: "ismap"}
+}
+
+// Itemid returns an itemid attribute with the given value.
+func Itemid(value string) *m.Attribute {
+	return &m.Attribute{Key: "itemid", Value: value}
+}
+
+// Itemprop returns an itemprop attribute with the given value.
+func Itemprop(value string) *m.Attribute {
+	return &m.Attribute{Key: "itemprop", Value: value}
+}
+
+// Itemref returns an itemref attribute with the given value.
+func Itemref(value string) *m.Attribute {
+	return &m.Attribute{Key: "itemref", Value: value}
+}
+
+// Itemscope returns an itemscope attribute, which is a boolean attribute.
+func Itemscope() *m.Attribute {
+	return &m.Attribute{Key: "itemscope"}
+}
+
+// Itemtype returns an itemtype attribute with the given value.
+func Itemtype(value string) *m.Attribute {
+	return &m.Attribute{Key: "itemtype", Value: value}
+}
+
+// Kind returns a kind attribute with the given value.
+func Kind(value string) *m.Attribute {
+	return &m.Attribute{Key: "kind", Value: value}
+}
+
+// LabelAttr returns a label attribute with the given value.
+func LabelAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "label", Value: value}
+}
+
+// Lang returns a lang attribute with the given value.
+func Lang(value string) *m.Attribute {
+	return &m.Attribute{Key: "lang", Value: value}
+}
+
+// List returns a list attribute with the given value.
+func List(value string) *m.Attribute {
+	return &m.Attribute{Key: "list", Value: value}
+}
+
+// Loading returns a loading attribute with the given value.
+func Loading(value string) *m.Attribute {
+	return &m.Attribute{Key: "loading", Value: value}
+}
+
+// Loop returns a loop attribute, which is a boolean attribute.
+func Loop() *m.Attribute {
+	return &m.Attribute{Key: "loop"}
+}
+
+// Low returns a low attribute with the given value.
+func Low(value string) *m.Attribute {
+	return &m.Attribute{Key: "low", Value: value}
+}
+
+// Max returns a max attribute with the given value.
+func Max(value string) *m.Attribute {
+	return &m.Attribute{Key: "max", Value: value}
+}
+
+// Maxlength returns a maxlength attribute with the given value.
+func Maxlength(value string) *m.Attribute {
+	return &m.Attribute{Key: "maxlength", Value: value}
+}
+
+// Media returns a media attribute with the given value.
+func Media(value string) *m.Attribute {
+	return &m.Attribute{Key: "media", Value: value}
+}
+
+// Method returns a method attribute with the given value.
+func Method(value string) *m.Attribute {
+	return &m.Attribute{Key: "method", Value: value}
+}
+
+// Min returns a min attribute with the given value.
+func Min(value string) *m.Attribute {
+	return &m.Attribute{Key: "min", Value: value}
+}
+
+// Minlength returns a minlength attribute with the given value.
+func Minlength(value string) *m.Attribute {
+	return &m.Attribute{Key: "minlength", Value: value}
+}
+
+// Multiple returns a multiple attribute, which is a boolean attribute.
+func Multiple() *m.Attribute {
+	return &m.Attribute{Key: "multiple"}
+}
+
+// Muted returns a muted attribute, which is a boolean attribute.
+func Muted() *m.Attribute {
+	return &m.Attribute{Key: "muted"}
+}
+
+// Name returns a name attribute with the given value.
+func Name(value string) *m.Attribute {
+	return &m.Attribute{Key: "name", Value: value}
+}
+
+// Nomodule returns a nomodule attribute, which is a boolean attribute.
+func Nomodule() *m.Attribute {
+	return &m.Attribute{Key: "nomodule"}
+}
+
+// Nonce returns a nonce attribute with the given value.
+func Nonce(value string) *m.Attribute {
+	return &m.Attribute{Key: "nonce", Value: value}
+}
+
+// Novalidate returns a novalidate attribute, which is a boolean attribute.
+func Novalidate() *m.Attribute {
+	return &m.Attribute{Key: "novalidate"}
+}
+
+// Open returns an open attribute, which is a boolean attribute.
+func Open() *m.Attribute {
+	return &m.Attribute{Key: "open"}
+}
+
+// Optimum returns an optimum attribute with the given value.
+func Optimum(value string) *m.Attribute {
+	return &m.Attribute{Key: "optimum", Value: value}
+}
+
+// Pattern returns a pattern attribute with the given value.
+func Pattern(value string) *m.Attribute {
+	return &m.Attribute{Key: "pattern", Value: value}
+}
+
+// Ping returns a ping attribute with the given value.
+func Ping(value string) *m.Attribute {
+	return &m.Attribute{Key: "ping", Value: value}
+}
+
+// Placeholder returns a placeholder attribute with the given value.
+func Placeholder(value string) *m.Attribute {
+	return &m.Attribute{Key: "placeholder", Value: value}
+}
+
+// Playsinline returns a playsinline attribute, which is a boolean attribute.
+func Playsinline() *m.Attribute {
+	return &m.Attribute{Key: "playsinline"}
+}
+
+// Popover returns a popover attribute with the given value.
+func Popover(value string) *m.Attribute {
+	return &m.Attribute{Key: "popover", Value: value}
+}
+
+// Popovertarget returns a popovertarget attribute with the given value.
+func Popovertarget(value string) *m.Attribute {
+	return &m.Attribute{Key: "popovertarget", Value: value}
+}
+
+// Popovertargetaction returns a popovertargetaction attribute with the given value.
+func Popovertargetaction(value string) *m.Attribute {
+	return &m.Attribute{Key: "popovertargetaction", Value: value}
+}
+
+// Poster returns a poster attribute with the given value.
+func Poster(value string) *m.Attribute {
+	return &m.Attribute{Key: "poster", Value: value}
+}
+
+// Preload returns a preload attribute with the given value.
+func Preload(value string) *m.Attribute {
+	return &m.Attribute{Key: "preload", Value: value}
+}
+
+// Readonly returns a readonly attribute, which is a boolean attribute.
+func Readonly() *m.Attribute {
+	return &m.Attribute{Key: "readonly"}
+}
+
+// Referrerpolicy returns a referrerpolicy attribute with the given value.
+func Referrerpolicy(value string) *m.Attribute {
+	return &m.Attribute{Key: "referrerpolicy", Value: value}
+}
+
+// Rel returns a rel attribute with the given value.
+func Rel(value string) *m.Attribute {
+	return &m.Attribute{Key: "rel", Value: value}
+}
+
+// Required returns a required attribute, which is a boolean attribute.
+func Required() *m.Attribute {
+	return &m.Attribute{Key: "required"}
+}
+
+// Reversed returns a reversed attribute, which is a boolean attribute.
+func Reversed() *m.Attribute {
+	return &m.Attribute{Key: "reversed"}
+}
+
+// Rows returns a rows attribute with the given value.
+func Rows(value string) *m.Attribute {
+	return &m.Attribute{Key: "rows", Value: value}
+}
+
+// Rowspan returns a rowspan attribute with the given value.
+func Rowspan(value string) *m.Attribute {
+	return &m.Attribute{Key: "rowspan", Value: value}
+}
+
+// Sandbox returns a sandbox attribute with the given value.
+func Sandbox(value string) *m.Attribute {
+	return &m.Attribute{Key: "sandbox", Value: value}
+}
+
+// Scope returns a scope attribute with the given value.
+func Scope(value string) *m.Attribute {
+	return &m.Attribute{Key: "scope", Value: value}
+}
+
+// Selected returns a selected attribute, which is a boolean attribute.
+func Selected() *m.Attribute {
+	return &m.Attribute{Key: "selected"}
+}
+
+// Shape returns a shape attribute with the given value.
+func Shape(value string) *m.Attribute {
+	return &m.Attribute{Key: "shape", Value: value}
+}
+
+// Size returns a size attribute with the given value.
+func Size(value string) *m.Attribute {
+	return &m.Attribute{Key: "size", Value: value}
+}
+
+// Sizes returns a sizes attribute with the given value.
+func Sizes(value string) *m.Attribute {
+	return &m.Attribute{Key: "sizes", Value: value}
+}
+
+// SlotAttr returns a slot attribute with the given value.
+func SlotAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "slot", Value: value}
+}
+
+// SpanAttr returns a span attribute with the given value.
+func SpanAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "span", Value: value}
+}
+
+// Spellcheck returns a spellcheck attribute with the given value.
+func Spellcheck(value string) *m.Attribute {
+	return &m.Attribute{Key: "spellcheck", Value: value}
+}
+
+// Src returns a src attribute with the given value.
+func Src(value string) *m.Attribute {
+	return &m.Attribute{Key: "src", Value: value}
+}
+
+// Srcdoc returns a srcdoc attribute with the given value.
+func Srcdoc(value string) *m.Attribute {
+	return &m.Attribute{Key: "srcdoc", Value: value}
+}
+
+// Srclang returns a srclang attribute with the given value.
+func Srclang(value string) *m.Attribute {
+	return &m.Attribute{Key: "srclang", Value: value}
+}
+
+// Srcset returns a srcset attribute with the given value.
+func Srcset(value string) *m.Attribute {
+	return &m.Attribute{Key: "srcset", Value: value}
+}
+
+// Start returns a start attribute with the given value.
+func Start(value string) *m.Attribute {
+	return &m.Attribute{Key: "start", Value: value}
+}
+
+// Step returns a step attribute with the given value.
+func Step(value string) *m.Attribute {
+	return &m.Attribute{Key: "step", Value: value}
+}
+
+// StyleAttr returns a style attribute with the given value.
+func StyleAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "style", Value: value}
+}
+
+// Tabindex returns a tabindex attribute with the given value.
+func Tabindex(value string) *m.Attribute {
+	return &m.Attribute{Key: "tabindex", Value: value}
+}
+
+// Target returns a target attribute with the given value.
+func Target(value string) *m.Attribute {
+	return &m.Attribute{Key: "target", Value: value}
+}
+
+// TitleAttr returns a title attribute with the given value.
+func TitleAttr(value string) *m.Attribute {
+	return &m.Attribute{Key: "title", Value: value}
+}
+
+// Translate returns a translate attribute with the given value.
+func Translate(value string) *m.Attribute {
+	return &m.Attribute{Key: "translate", Value: value}
+}
+
+// Type returns a type attribute with the given value.
+func Type(value string) *m.Attribute {
+	return &m.Attribute{Key: "type", Value: value}
+}
+
+// Usemap returns a usemap attribute with the given value.
+func Usemap(value string) *m.Attribute {
+	return &m.Attribute{Key: "usemap", Value: value}
+}
+
+// Value returns a value attribute with the given value.
+func Value(value string) *m.Attribute {
+	return &m.Attribute{Key: "value", Value: value}
+}
+
+// Width returns a width attribute with the given value.
+func Width(value string) *m.Attribute {
+	return &m.Attribute{Key: "width", Value: value}
+}
+
+// Wrap returns a wrap attribute with the given value.
+func Wrap(value string) *m.Attribute {
+	return &m.Attribute{Key: "wrap", Value: value}
+}
@@ -0,0 +1,567 @@
+// Code generated by layeh.com/m/h/gen; DO NOT EDIT.
+
+package h
+
+import (
+	"layeh.com/m"
+)
+
+// A returns an a element.
+func A(elements ...m.Element) m.Element {
+	return m.M("a", elements...)
+}
+
+// Abbr returns an abbr element.
+func Abbr(elements ...m.Element) m.Element {
+	return m.M("abbr", elements...)
+}
+
+// Address returns an address element.
+func Address(elements ...m.Element) m.Element {
+	return m.M("address", elements...)
+}
+
+// Area returns an area element, which is a void element.
+func Area(attributes ...*m.Attribute) m.Element {
+	return m.M("area", void(attributes)...)
+}
+
+// Article returns an article element.
+func Article(elements ...m.Element) m.Element {
+	return m.M("article", elements...)
+}
+
+// Aside returns an aside element.
+func Aside(elements ...m.Element) m.Element {
+	return m.M("aside", elements...)
+}
+
+// Audio returns an audio element.
+func Audio(elements ...m.Element) m.Element {
+	return m.M("audio", elements...)
+}
+
+// B returns a b element.
+func B(elements ...m.Element) m.Element {
+	return m.M("b", elements...)
+}
+
+// Base returns a base element, which is a void element.
+func Base(attributes ...*m.Attribute) m.Element {
+	return m.M("base", void(attributes)...)
+}
+
+// Bdi returns a bdi element.
+func Bdi(elements ...m.Element) m.Element {
+	return m.M("bdi", elements...)
+}
+
+// Bdo returns a bdo element.
+func Bdo(elements ...m.Element) m.Element {
+	return m.M("bdo", elements...)
+}
+
+// Blockquote returns a blockquote element.
+func Blockquote(elements ...m.Element) m.Element {
+	return m.M("blockquote", elements...)
+}
+
+// Body returns a body element.
+func Body(elements ...m.Element) m.Element {
+	return m.M("body", elements...)
+}
+
+// Br returns a br element, which is a void element.
+func Br(attributes ...*m.Attribute) m.Element {
+	return m.M("br", void(attributes)...)
+}
+
+// Button returns a button element.
+func Button(elements ...m.Element) m.Element {
+	return m.M("button", elements...)
+}
+
+// Canvas returns a canvas element.
+func Canvas(elements ...m.Element) m.Element {
+	return m.M("canvas", elements...)
+}
+
+// Caption returns a caption element.
+func Caption(elements ...m.Element) m.Element {
+	return m.M("caption", elements...)
+}
+
+// Cite returns a cite element.
+func Cite(elements ...m.Element) m.Element {
+	return m.M("cite", elements...)
+}
+
+// Code returns a code element.
+func Code(elements ...m.Element) m.Element {
+	return m.M("code", elements...)
+}
+
+// Col returns a col element, which is a void element.
+func Col(attributes ...*m.Attribute) m.Element {
+	return m.M("col", void(attributes)...)
+}
+
+// Colgroup returns a colgroup element.
+func Colgroup(elements ...m.Element) m.Element {
+	return m.M("colgroup", elements...)
+}
+
+// Data returns a data element.
+func Data(elements ...m.Element) m.Element {
+	return m.M("data", elements...)
+}
+
+// Datalist returns a datalist element.
+func Datalist(elements ...m.Element) m.Element {
+	return m.M("datalist", elements...)
+}
+
+// Dd returns a dd element.
+func Dd(elements ...m.Element) m.Element {
+	return m.M("dd", elements...)
+}
+
+// Del returns a del element.
+func Del(elements ...m.Element) m.Element {
+	return m.M("del", elements...)
+}
+
+// Details returns a details element.
+func Details(elements ...m.Element) m.Element {
+	return m.M("details", elements...)
+}
+
+// Dfn returns a dfn element.
+func Dfn(elements ...m.Element) m.Element {
+	return m.M("dfn", elements...)
+}
+
+// Dialog returns a dialog element.
+func Dialog(elements ...m.Element) m.Element {
+	return m.M("dialog", elements...)
+}
+
+// Div returns a div element.
+func Div(elements ...m.Element) m.Element {
+	return m.M("div", elements...)
+}
+
+// Dl returns a dl element.
+func Dl(elements ...m.Element) m.Element {
+	return m.M("dl", elements...)
+}
+
+// Dt returns a dt element.
+func Dt(elements ...m.Element) m.Element {
+	return m.M("dt", elements...)
+}
+
+// Em returns an em element.
+func Em(elements ...m.Element) m.Element {
+	return m.M("em", elements...)
+}
+
+// Embed returns an embed element, which is a void element.
+func Embed(attributes ...*m.Attribute) m.Element {
+	return m.M("embed", void(attributes)...)
+}
+
+// Fieldset returns a fieldset element.
+func Fieldset(elements ...m.Element) m.Element {
+	return m.M("fieldset", elements...)
+}
+
+// Figcaption returns a figcaption element.
+func Figcaption(elements ...m.Element) m.Element {
+	return m.M("figcaption", elements...)
+}
+
+// Figure returns a figure element.
+func Figure(elements ...m.Element) m.Element {
+	return m.M("figure", elements...)
+}
+
+// Footer returns a footer element.
+func Footer(elements ...m.Element) m.Element {
+	return m.M("footer", elements...)
+}
+
+// Form returns a form element.
+func Form(elements ...m.Element) m.Element {
+	return m.M("form", elements...)
+}
+
+// H1 returns an h1 element.
+func H1(elements ...m.Element) m.Element {
+	return m.M("h1", elements...)
+}
+
+// H2 returns an h2 element.
+func H2(elements ...m.Element) m.Element {
+	return m.M("h2", elements...)
+}
+
+// H3 returns an h3 element.
+func H3(elements ...m.Element) m.Element {
+	return m.M("h3", elements...)
+}
+
+// H4 returns an h4 element.
+func H4(elements ...m.Element) m.Element {
+	return m.M("h4", elements...)
+}
+
+// H5 returns an h5 element.
+func H5(elements ...m.Element) m.Element {
+	return m.M("h5", elements...)
+}
+
+// H6 returns an h6 element.
+func H6(elements ...m.Element) m.Element {
+	return m.M("h6", elements...)
+}
+
+// Head returns a head element.
+func Head(elements ...m.Element) m.Element {
+	return m.M("head", elements...)
+}
+
+// Header returns a header element.
+func Header(elements ...m.Element) m.Element {
+	return m.M("header", elements...)
+}
+
+// Hgroup returns an hgroup element.
+func Hgroup(elements ...m.Element) m.Element {
+	return m.M("hgroup", elements...)
+}
+
+// Hr returns an hr element, which is a void element.
+func Hr(attributes ...*m.Attribute) m.Element {
+	return m.M("hr", void(attributes)...)
+}
+
+// Html returns an html element.
+func Html(elements ...m.Element) m.Element {
+	return m.M("html", elements...)
+}
+
+// I returns an i element.
+func I(elements ...m.Element) m.Element {
+	return m.M("i", elements...)
+}
+
+// Iframe returns an iframe element.
+func Iframe(elements ...m.Element) m.Element {
+	return m.M("iframe", elements...)
+}
+
+// Img returns an img element, which is a void element.
+func Img(attributes ...*m.Attribute) m.Element {
+	return m.M("img", void(attributes)...)
+}
+
+// Input returns an input element, which is a void element.
+func Input(attributes ...*m.Attribute) m.Element {
+	return m.M("input", void(attributes)...)
+}
+
+// Ins returns an ins element.
+func Ins(elements ...m.Element) m.Element {
+	return m.M("ins", elements...)
+}
+
+// Kbd returns a kbd element.
+func Kbd(elements ...m.Element) m.Element {
+	return m.M("kbd", elements...)
+}
+
+// Label returns a label element.
+func Label(elements ...m.Element) m.Element {
+	return m.M("label", elements...)
+}
+
+// Legend returns a legend element.
+func Legend(elements ...m.Element) m.Element {
+	return m.M("legend", elements...)
+}
+
+// Li returns a li element.
+func Li(elements ...m.Element) m.Element {
+	return m.M("li", elements...)
+}
+
+// Link returns a link element, which is a void element.
+func Link(attributes ...*m.Attribute) m.Element {
+	return m.M("link", void(attributes)...)
+}
+
+// Main returns a main element.
+func Main(elements ...m.Element) m.Element {
+	return m.M("main", elements...)
+}
+
+// Map returns a map element.
+func Map(elements ...m.Element) m.Element {
+	return m.M("map", elements...)
+}
+
+// Mark returns a mark element.
+func Mark(elements ...m.Element) m.Element {
+	return m.M("mark", elements...)
+}
+
+// Menu returns a menu element.
+func Menu(elements ...m.Element) m.Element {
+	return m.M("menu", elements...)
+}
+
+// Meta returns a meta element, which is a void element.
+func Meta(attributes ...*m.Attribute) m.Element {
+	return m.M("meta", void(attributes)...)
+}
+
+// Meter returns a meter element.
+func Meter(elements ...m.Element) m.Element {
+	return m.M("meter", elements...)
+}
+
+// Nav returns a nav element.
+func Nav(elements ...m.Element) m.Element {
+	return m.M("nav", elements...)
+}
+
+// Noscript returns a noscript element.
+func Noscript(elements ...m.Element) m.Element {
+	return m.M("noscript", elements...)
+}
+
+// Object returns an object element.
+func Object(elements ...m.Element) m.Element {
+	return m.M("object", elements...)
+}
+
+// Ol returns an ol element.
+func Ol(elements ...m.Element) m.Element {
+	return m.M("ol", elements...)
+}
+
+// Optgroup returns an optgroup element.
+func Optgroup(elements ...m.Element) m.Element {
+	return m.M("optgroup", elements...)
+}
+
+// Option returns an option element.
+func Option(elements ...m.Element) m.Element {
+	return m.M("option", elements...)
+}
+
+// Output returns an output element.
+func Output(elements ...m.Element) m.Element {
+	return m.M("output", elements...)
+}
+
+// P returns a p element.
+func P(elements ...m.Element) m.Element {
+	return m.M("p", elements...)
+}
+
+// Picture returns a picture element.
+func Picture(elements ...m.Element) m.Element {
+	return m.M("picture", elements...)
+}
+
+// Pre returns a pre element.
+func Pre(elements ...m.Element) m.Element {
+	return m.M("pre", elements...)
+}
+
+// Progress returns a progress element.
+func Progress(elements ...m.Element) m.Element {
+	return m.M("progress", elements...)
+}
+
+// Q returns a q element.
+func Q(elements ...m.Element) m.Element {
+	return m.M("q", elements...)
+}
+
+// Rp returns a rp element.
+func Rp(elements ...m.Element) m.Element {
+	return m.M("rp", elements...)
+}
+
+// Rt returns a rt element.
+func Rt(elements ...m.Element) m.Element {
+	return m.M("rt", elements...)
+}
+
+// Ruby returns a ruby element.
+func Ruby(elements ...m.Element) m.Element {
+	return m.M("ruby", elements...)
+}
+
+// S returns an s element.
+func S(elements ...m.Element) m.Element {
+	return m.M("s", elements...)
+}
+
+// Samp returns a samp element.
+func Samp(elements ...m.Element) m.Element {
+	return m.M("samp", elements...)
+}
+
+// Script returns a script element.
+func Script(elements ...m.Element) m.Element {
+	return m.M("script", elements...)
+}
+
+// Search returns a search element.
+func Search(elements ...m.Element) m.Element {
+	return m.M("search", elements...)
+}
+
+// Section returns a section element.
+func Section(elements ...m.Element) m.Element {
+	return m.M("section", elements...)
+}
+
+// Select returns a select element.
+func Select(elements ...m.Element) m.Element {
+	return m.M("select", elements...)
+}
+
+// Slot returns a slot element.
+func Slot(elements ...m.Element) m.Element {
+	return m.M("slot", elements...)
+}
+
+// Small returns a small element.
+func Small(elements ...m.Element) m.Element {
+	return m.M("small", elements...)
+}
+
+// Source returns a source element, which is a void element.
+func Source(attributes ...*m.Attribute) m.Element {
+	return m.M("source", void(attributes)...)
+}
+
+// Span returns a span element.
+func Span(elements ...m.Element) m.Element {
+	return m.M("span", elements...)
+}
+
+// Strong returns a strong element.
+func Strong(elements ...m.Element) m.Element {
+	return m.M("strong", elements...)
+}
+
+// Style returns a style element.
+func Style(elements ...m.Element) m.Element {
+	return m.M("style", elements...)
+}
+
+// Sub returns a sub element.
+func Sub(elements ...m.Element) m.Element {
+	return m.M("sub", elements...)
+}
+
+// Summary returns a summary element.
+func Summary(elements ...m.Element) m.Element {
+	return m.M("summary", elements...)
+}
+
+// Sup returns a sup element.
+func Sup(elements ...m.Element) m.Element {
+	return m.M("sup", elements...)
+}
+
+// Table returns a table element.
+func Table(elements ...m.Element) m.Element {
+	return m.M("table", elements...)
+}
+
+// Tbody returns a tbody element.
+func Tbody(elements ...m.Element) m.Element {
+	return m.M("tbody", elements...)
+}
+
+// Td returns a td element.
+func Td(elements ...m.Element) m.Element {
+	return m.M("td", elements...)
+}
+
+// Template returns a template element.
+func Template(elements ...m.Element) m.Element {
+	return m.M("template", elements...)
+}
+
+// Textarea returns a textarea element.
+func Textarea(elements ...m.Element) m.Element {
+	return m.M("textarea", elements...)
+}
+
+// Tfoot returns a tfoot element.
+func Tfoot(elements ...m.Element) m.Element {
+	return m.M("tfoot", elements...)
+}
+
+// Th returns a th element.
+func Th(elements ...m.Element) m.Element {
+	return m.M("th", elements...)
+}
+
+// Thead returns a thead element.
+func Thead(elements ...m.Element) m.Element {
+	return m.M("thead", elements...)
+}
+
+// Time returns a time element.
+func Time(elements ...m.Element) m.Element {
+	return m.M("time", elements...)
+}
+
+// Title returns a title element.
+func Title(elements ...m.Element) m.Element {
+	return m.M("title", elements...)
+}
+
+// Tr returns a tr element.
+func Tr(elements ...m.Element) m.Element {
+	return m.M("tr", elements...)
+}
+
+// Track returns a track element, which is a void element.
+func Track(attributes ...*m.Attribute) m.Element {
+	return m.M("track", void(attributes)...)
+}
+
+// U returns a u element.
+func U(elements ...m.Element) m.Element {
+	return m.M("u", elements...)
+}
+
+// Ul returns a ul element.
+func Ul(elements ...m.Element) m.Element {
+	return m.M("ul", elements...)
+}
+
+// Var returns a var element.
+func Var(elements ...m.Element) m.Element {
+	return m.M("var", elements...)
+}
+
+// Video returns a video element.
+func Video(elements ...m.Element) m.Element {
+	return m.M("video", elements...)
+}
+
+// Wbr returns a wbr element, which is a void element.
+func Wbr(attributes ...*m.Attribute) m.Element {
+	return m.M("wbr", void(attributes)...)
+}
@@ -0,0 +1,5 @@
+module layeh.com/m/h/gen
+
+go 1.23.0
+
+require golang.org/x/net v0.35.0
@@ -0,0 +1,354 @@
+// Command gen generates elements.go and attributes.go of package h.
+//
+// The elements and attributes are read from the element and attribute
+// indexes of the HTML specification, excluding foreign (SVG and MathML)
+// elements and event handler attributes. Run with:
+//
+//	go generate layeh.com/m/h
+//
+// The -spec flag reads the index page from a file instead of downloading it,
+// and the -dir flag sets the directory of package h.
+//
+// The name of a function is its tag name or key with the first letter and
+// each letter following a hyphen in uppercase, and the hyphens removed (e.g.
+// Br, Tbody and HttpEquiv). Attributes that have the same name as an element
+// have an Attr suffix.
+//
+// gen is a separate module, so that layeh.com/m does not depend on
+// golang.org/x/net.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const specURL = "https://html.spec.whatwg.org/multipage/indices.html"
+
+var (
+	spec = flag.String("spec", "", "read the index page of the HTML specification from `file` instead of "+specURL)
+	dir  = flag.String("dir", "..", "write the files to the `directory` of package h")
+)
+
+type element struct {
+	TagName string
+	Name    string
+	Void    bool
+}
+
+type attribute struct {
+	Key     string
+	Name    string
+	Boolean bool
+}
+
+// foreignElements are listed in the element index, but are not HTML
+// elements.
+var foreignElements = map[string]bool{
+	"math": true,
+	"svg":  true,
+}
+
+var funcs = template.FuncMap{
+	// article returns the indefinite article of name, as it is read
+	// aloud (e.g. an href, a u).
+	"article": func(name string) string {
+		// The names that start with u are read as "you" (e.g. ul).
+		vowels := "aeio"
+		if len(name) == 1 {
+			vowels = "aefhilmnorsx"
+		} else if name[0] == 'h' && !strings.ContainsRune(vowels+"u", rune(name[1])) {
+			return "an"
+		}
+		if strings.ContainsRune(vowels, rune(name[0])) {
+			return "an"
+		}
+		return "a"
+	},
+}
+
+var elementsTemplate = template.Must(template.New("").Funcs(funcs).Parse(`// Code generated by layeh.com/m/h/gen; DO NOT EDIT.
+
+package h
+
+import (
+	"layeh.com/m"
+)
+{{range .}}
+{{if .Void -}}
+// {{.Name}} returns {{article .TagName}} {{.TagName}} element, which is a void element.
+func {{.Name}}(attributes ...*m.Attribute) m.Element {
+	return m.M("{{.TagName}}", void(attributes)...)
+}
+{{- else -}}
+// {{.Name}} returns {{article .TagName}} {{.TagName}} element.
+func {{.Name}}(elements ...m.Element) m.Element {
+	return m.M("{{.TagName}}", elements...)
+}
+{{- end}}
+{{end}}`))
+
+var attributesTemplate = template.Must(template.New("").Funcs(funcs).Parse(`// Code generated by layeh.com/m/h/gen; DO NOT EDIT.
+
+package h
+
+import (
+	"layeh.com/m"
+)
+{{range .}}
+{{if .Boolean -}}
+// {{.Name}} returns {{article .Key}} {{.Key}} attribute, which is a boolean attribute.
+func {{.Name}}() *m.Attribute {
+	return &m.Attribute{Key: "{{.Key}}"}
+}
+{{- else -}}
+// {{.Name}} returns {{article .Key}} {{.Key}} attribute with the given value.
+func {{.Name}}(value string) *m.Attribute {
+	return &m.Attribute{Key: "{{.Key}}", Value: value}
+}
+{{- end}}
+{{end}}`))
+
+func main() {
+	flag.Parse()
+
+	doc, err := load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	elements, err := parseElements(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attributes, err := parseAttributes(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for i := range elements {
+		e := &elements[i]
+		e.Name = name(e.TagName)
+		names[e.Name] = true
+	}
+	for i := range attributes {
+		a := &attributes[i]
+		a.Name = name(a.Key)
+		if names[a.Name] {
+			a.Name += "Attr"
+		}
+	}
+
+	generate(filepath.Join(*dir, "elements.go"), elementsTemplate, elements)
+	generate(filepath.Join(*dir, "attributes.go"), attributesTemplate, attributes)
+}
+
+func load() (*html.Node, error) {
+	var r io.Reader
+	if *spec != "" {
+		f, err := os.Open(*spec)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		resp, err := http.Get(specURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", specURL, resp.Status)
+		}
+		r = resp.Body
+	}
+	return html.Parse(r)
+}
+
+// name returns the function name of a tag name or key.
+func name(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "-") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// parseElements parses the "List of elements" table. The first cell of a
+// row contains the tag names, and the children cell is "empty" for void
+// elements.
+func parseElements(doc *html.Node) ([]element, error) {
+	rows, err := table(doc, "List of elements")
+	if err != nil {
+		return nil, err
+	}
+	var elements []element
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		void := text(row[4]) == "empty"
+		for _, tagName := range codes(row[0]) {
+			if foreignElements[tagName] || seen[tagName] {
+				continue
+			}
+			seen[tagName] = true
+			elements = append(elements, element{TagName: tagName, Void: void})
+		}
+	}
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("no elements found")
+	}
+	sort.Slice(elements, func(i, j int) bool {
+		return elements[i].TagName < elements[j].TagName
+	})
+	return elements, nil
+}
+
+// parseAttributes parses the "List of attributes" table. An attribute
+// appears in a row for each element it applies to, and is boolean if its
+// value cell is "Boolean attribute" in each of them.
+func parseAttributes(doc *html.Node) ([]attribute, error) {
+	rows, err := table(doc, "List of attributes")
+	if err != nil {
+		return nil, err
+	}
+	boolean := make(map[string]bool)
+	for _, row := range rows {
+		if len(row) < 4 {
+			continue
+		}
+		isBoolean := text(row[3]) == "Boolean attribute"
+		for _, key := range codes(row[0]) {
+			if b, ok := boolean[key]; ok {
+				isBoolean = isBoolean && b
+			}
+			boolean[key] = isBoolean
+		}
+	}
+	if len(boolean) == 0 {
+		return nil, fmt.Errorf("no attributes found")
+	}
+	attributes := make([]attribute, 0, len(boolean))
+	for key, isBoolean := range boolean {
+		attributes = append(attributes, attribute{Key: key, Boolean: isBoolean})
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Key < attributes[j].Key
+	})
+	return attributes, nil
+}
+
+// table returns the cells of the body rows of the table whose caption
+// starts with caption.
+func table(doc *html.Node, caption string) ([][]*html.Node, error) {
+	var rows [][]*html.Node
+	found := false
+	walk(doc, func(n *html.Node) bool {
+		if found || n.DataAtom != atom.Table {
+			return !found
+		}
+		c := child(n, atom.Caption)
+		if c == nil || !strings.HasPrefix(text(c), caption) {
+			return true
+		}
+		found = true
+		tbody := child(n, atom.Tbody)
+		if tbody == nil {
+			return false
+		}
+		for tr := tbody.FirstChild; tr != nil; tr = tr.NextSibling {
+			if tr.DataAtom != atom.Tr {
+				continue
+			}
+			var row []*html.Node
+			for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.DataAtom == atom.Th || cell.DataAtom == atom.Td {
+					row = append(row, cell)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return false
+	})
+	if !found {
+		return nil, fmt.Errorf("table %q not found", caption)
+	}
+	return rows, nil
+}
+
+// codes returns the text of the code elements of n, which are the names in
+// the first cell of a row.
+func codes(n *html.Node) []string {
+	var names []string
+	walk(n, func(n *html.Node) bool {
+		if n.DataAtom == atom.Code {
+			names = append(names, text(n))
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+func child(n *html.Node, a atom.Atom) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == a {
+			return c
+		}
+	}
+	return nil
+}
+
+// walk calls f for n and its descendants. The children of a node are not
+// visited if f returns false.
+func walk(n *html.Node, f func(n *html.Node) bool) {
+	if !f(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, f)
+	}
+}
+
+func text(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(n *html.Node) bool {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func generate(filename string, t *template.Template, data interface{}) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		log.Fatal(err)
+	}
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filename, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,40 @@
+// Package h provides typed constructors for HTML elements and attributes.
+//
+// Each element function returns the same element as M with the tag name as
+// the selector, and each attribute function returns an *m.Attribute, so that
+// the names are checked at compile time:
+//
+//	h.Label(
+//		h.Input(h.Type("checkbox"), h.Name("agree"), h.Checked()),
+//		m.T(" I agree"),
+//	)
+//
+// As with M, attributes must be the first arguments of an element. Void
+// elements (e.g. Input) can only be given attributes.
+//
+// The name of a function is the tag name or key with its first letter, and
+// each letter following a hyphen, in uppercase (e.g. Br, Tbody and
+// HttpEquiv). Attributes that have the same name as an element have an Attr
+// suffix (e.g. TitleAttr and StyleAttr). Boolean attributes take no value.
+//
+// The functions are generated from the element and attribute indexes of the
+// HTML specification by the command in the gen directory.
+package h
+
+import (
+	"layeh.com/m"
+)
+
+//go:generate go -C gen run .
+
+// void returns the elements of a void element. nil attributes are skipped,
+// such as for conditional attributes.
+func void(attributes []*m.Attribute) []m.Element {
+	elements := make([]m.Element, 0, len(attributes))
+	for _, attribute := range attributes {
+		if attribute != nil {
+			elements = append(elements, attribute)
+		}
+	}
+	return elements
+}
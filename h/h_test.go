@@ -0,0 +1,44 @@
+package h
+
+import (
+	"testing"
+
+	"layeh.com/m"
+)
+
+func TestElements(t *testing.T) {
+	var disabled *m.Attribute
+	tests := []struct {
+		Element  m.Element
+		Expected m.Element
+	}{
+		{Div(), m.M("div")},
+		{Div(Class("a"), Id("b"), m.T("c")), m.M("div#b.a", m.T("c"))},
+		{A(Href("/"), TitleAttr("Home"), m.T("Home")), m.M("a[href=/][title=Home]", m.T("Home"))},
+		{Input(Type("checkbox"), Checked(), disabled), m.M("input[type=checkbox][checked]")},
+		{Input(disabled), m.M("input")},
+		{Button(disabled, Type("submit"), disabled, m.T("x")), m.M("button[type=submit]", m.T("x"))},
+		{P(disabled), m.M("p")},
+		{Label(For("x"), LabelAttr("y")), m.M("label[for=x][label=y]")},
+		{Br(), m.M("br")},
+		{Hr(), m.M("hr")},
+		{Wbr(), m.M("wbr")},
+		{Ul(Li(m.T("a")), Li(m.T("b"))), m.M("ul", m.M("li", m.T("a")), m.M("li", m.T("b")))},
+		{Dl(Dt(m.T("a")), Dd(m.T("b"))), m.M("dl", m.M("dt", m.T("a")), m.M("dd", m.T("b")))},
+		{Table(Tbody(Tr(Td(Colspan("2"))))), m.M("table", m.M("tbody", m.M("tr", m.M("td[colspan=2]"))))},
+		{Textarea(Rows("3"), Readonly(), m.T("<text>")), m.M("textarea[rows=3][readonly]", m.T("<text>"))},
+		{Meta(HttpEquiv("refresh"), Content("0")), m.M("meta[http-equiv=refresh][content=0]")},
+		{Form(AcceptCharset("utf-8"), Novalidate()), m.M("form[accept-charset=utf-8][novalidate]")},
+		{Html(Lang("en"), Body(StyleAttr("color: red"))), m.M("html[lang=en]", m.M("body[style=color: red]"))},
+		{Img(Src("a.png"), Alt("")), m.M("img[src=a.png][alt=]")},
+		{Blockquote(CiteAttr("/"), m.T("a")), m.M("blockquote[cite=/]", m.T("a"))},
+		{Data(DataAttr("x")), m.M("data[data=x]")},
+	}
+
+	for _, tt := range tests {
+		got, expected := m.RenderString(tt.Element), m.RenderString(tt.Expected)
+		if got != expected {
+			t.Errorf("got %#v; expected %#v", got, expected)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package css parses the subset of CSS that is needed to inline and merge
+// style declarations.
+package css
+
+import (
+	"strings"
+)
+
+// Rule is a CSS style rule.
+type Rule struct {
+	Selectors    []string
+	Declarations []Declaration
+}
+
+// Declaration is a CSS property declaration.
+type Declaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+// ParseStylesheet returns the style rules of css, and the text of the
+// @media rules, which cannot be inlined. Other at-rules are removed.
+func ParseStylesheet(css string) (rules []Rule, media string) {
+	css = removeComments(css)
+	var mediaRules strings.Builder
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			break
+		}
+		open := indexOutside(css, '{')
+		semicolon := indexOutside(css, ';')
+		if css[0] == '@' && semicolon != -1 && (open == -1 || semicolon < open) {
+			// Statement at-rule (e.g. @import).
+			css = css[semicolon+1:]
+			continue
+		}
+		if open == -1 {
+			break
+		}
+		end := matchingBrace(css, open)
+		prelude := strings.TrimSpace(css[:open])
+		block := css[open+1 : end]
+		if strings.HasPrefix(prelude, "@") {
+			if strings.HasPrefix(strings.ToLower(prelude), "@media") {
+				mediaRules.WriteString(css[:end])
+				mediaRules.WriteString("}\n")
+			}
+		} else {
+			var selectors []string
+			for _, s := range splitOutside(prelude, ',') {
+				if s = strings.TrimSpace(s); s != "" {
+					selectors = append(selectors, s)
+				}
+			}
+			rules = append(rules, Rule{
+				Selectors:    selectors,
+				Declarations: ParseDeclarations(block),
+			})
+		}
+		if end == len(css) {
+			break
+		}
+		css = css[end+1:]
+	}
+	return rules, mediaRules.String()
+}
+
+// ParseDeclarations parses the declarations of a rule block or a style
+// attribute.
+func ParseDeclarations(s string) []Declaration {
+	var declarations []Declaration
+	for _, d := range splitOutside(s, ';') {
+		colon := strings.IndexByte(d, ':')
+		if colon == -1 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(d[:colon]))
+		value := strings.TrimSpace(d[colon+1:])
+		important := false
+		if i := strings.LastIndexByte(value, '!'); i != -1 && strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+			value = strings.TrimSpace(value[:i])
+			important = true
+		}
+		if property == "" || value == "" {
+			continue
+		}
+		declarations = append(declarations, Declaration{
+			Property:  property,
+			Value:     value,
+			Important: important,
+		})
+	}
+	return declarations
+}
+
+func removeComments(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "/*")
+		if start == -1 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:start])
+		end := strings.Index(s[start+2:], "*/")
+		if end == -1 {
+			return b.String()
+		}
+		s = s[start+2+end+2:]
+	}
+}
+
+// scan calls fn with the index of each byte of s that is not inside of a
+// string or parentheses, and stops when fn returns false.
+func scan(s string, fn func(i int) bool) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+			continue
+		case c == '(':
+			depth++
+			continue
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 && !fn(i) {
+			return
+		}
+	}
+}
+
+func indexOutside(s string, c byte) int {
+	index := -1
+	scan(s, func(i int) bool {
+		if s[i] == c {
+			index = i
+			return false
+		}
+		return true
+	})
+	return index
+}
+
+func splitOutside(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	scan(s, func(i int) bool {
+		if s[i] == sep {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	return append(parts, s[start:])
+}
+
+// matchingBrace returns the index of the brace that closes the one at open,
+// or len(s) if it is not closed.
+func matchingBrace(s string, open int) int {
+	end := len(s)
+	depth := 0
+	scan(s[open:], func(i int) bool {
+		switch s[open+i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				end = open + i
+				return false
+			}
+		}
+		return true
+	})
+	return end
+}
+
+// ValidProperty reports whether name is a property name, such as color or
+// --custom-property.
+func ValidProperty(name string) bool {
+	if strings.HasPrefix(name, "--") {
+		name = name[2:]
+	} else {
+		name = strings.TrimPrefix(name, "-")
+		if name == "" || !isLetter(name[0]) {
+			return false
+		}
+	}
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isLetter(c) && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// ValidValue reports whether value is a non-empty property value that cannot
+// end its declaration or change the meaning of the declarations that follow
+// it. Strings and parentheses must be closed, and the value must not contain
+// ;, {, }, comments, backslash escapes, control characters, expression() or
+// script URLs.
+func ValidValue(value string) bool {
+	if strings.TrimSpace(value) == "" {
+		return false
+	}
+	var quote byte
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < ' ' && c != '\t' || c == 0x7F || c == '\\' {
+			return false
+		}
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(':
+			depth++
+			name := strings.ToLower(functionName(value[:i]))
+			if name == "expression" || name == "url" && isScriptURL(value[i+1:]) {
+				return false
+			}
+		case ')':
+			if depth--; depth < 0 {
+				return false
+			}
+		case ';', '{', '}', '<', '>':
+			return false
+		case '/':
+			if i+1 < len(value) && value[i+1] == '*' {
+				return false
+			}
+		}
+	}
+	return quote == 0 && depth == 0
+}
+
+// functionName returns the identifier at the end of s, which is the name of
+// a function if s is followed by a parenthesis. Whitespace before the
+// parenthesis is ignored, as by some old browsers.
+func functionName(s string) string {
+	s = strings.TrimRight(s, " \t")
+	i := len(s)
+	for i > 0 && (isLetter(s[i-1]) || s[i-1] == '-') {
+		i--
+	}
+	return s[i:]
+}
+
+// isScriptURL reports whether the argument of url() at the start of s is a
+// javascript: or vbscript: URL.
+func isScriptURL(s string) bool {
+	s = strings.TrimLeft(s, " \t\"'")
+	s = strings.ToLower(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, s))
+	return strings.HasPrefix(s, "javascript:") || strings.HasPrefix(s, "vbscript:")
+}
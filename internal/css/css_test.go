@@ -0,0 +1,88 @@
+package css
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStylesheet(t *testing.T) {
+	rules, media := ParseStylesheet(`a { color: red; } b, i { font: 12px/1 "a;b" } @media print { a { color: black } } @page { margin: 0 }`)
+	expected := []Rule{
+		{
+			Selectors:    []string{"a"},
+			Declarations: []Declaration{{Property: "color", Value: "red"}},
+		},
+		{
+			Selectors:    []string{"b", "i"},
+			Declarations: []Declaration{{Property: "font", Value: `12px/1 "a;b"`}},
+		},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rules)
+	}
+	if !strings.HasPrefix(media, "@media print { a { color: black } }") {
+		t.Errorf("unexpected media rules %q", media)
+	}
+}
+
+func TestValidProperty(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Valid bool
+	}{
+		{"color", true},
+		{"Margin-Top", true},
+		{"-webkit-box-shadow", true},
+		{"--main-color", true},
+		{"--_1", true},
+		{"", false},
+		{"-", false},
+		{"--", false},
+		{"1px", false},
+		{"color:red", false},
+		{"a b", false},
+		{"a;b", false},
+	}
+	for _, tt := range tests {
+		if valid := ValidProperty(tt.Name); valid != tt.Valid {
+			t.Errorf("ValidProperty(%q) = %t; expected %t", tt.Name, valid, tt.Valid)
+		}
+	}
+}
+
+func TestValidValue(t *testing.T) {
+	tests := []struct {
+		Value string
+		Valid bool
+	}{
+		{"red", true},
+		{"0 auto", true},
+		{`12px/1.5 "Helvetica Neue", sans-serif`, true},
+		{"calc(100% - (2 * 1em))", true},
+		{`url("a.png") no-repeat`, true},
+		{`"a;b{c}"`, true},
+		{"bold !important", true},
+		{"", false},
+		{" ", false},
+		{"red; background: url(x)", false},
+		{"red}", false},
+		{"a /* b */", false},
+		{`"a`, false},
+		{"calc(1px", false},
+		{"1px)", false},
+		{`\65xpression(alert(1))`, false},
+		{"expression(alert(1))", false},
+		{"EXPRESSION (alert(1))", false},
+		{"url(javascript:alert(1))", false},
+		{`url( " JavaScript :alert(1)")`, false},
+		{"url(vbscript:x)", false},
+		{"a\nb", false},
+		{"a\x00", false},
+	}
+	for _, tt := range tests {
+		if valid := ValidValue(tt.Value); valid != tt.Valid {
+			t.Errorf("ValidValue(%q) = %t; expected %t", tt.Value, valid, tt.Valid)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package fuzz tests that the HTML rendered by layeh.com/m is parsed back by
+// the HTML parser of golang.org/x/net/html into the same tree.
+//
+// It is a separate module, so that layeh.com/m does not depend on
+// golang.org/x/net.
+package fuzz
@@ -0,0 +1,205 @@
+package fuzz
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"testing"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"layeh.com/m"
+)
+
+var (
+	fuzzTags       = []string{"div", "span", "em", "strong", "section", "code", "br", "img"}
+	fuzzAttributes = []string{"id", "class", "title", "data-x", "href"}
+)
+
+// fuzzElement returns an element built by ops. Each byte of ops adds a child
+// element, ends the current element, adds text, or adds an attribute.
+func fuzzElement(ops, text, value string) m.Element {
+	type frame struct {
+		TagName  string
+		Elements []m.Element
+		Keys     map[string]bool
+	}
+	stack := []*frame{{TagName: "div", Keys: map[string]bool{}}}
+	end := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		parent := stack[len(stack)-1]
+		parent.Elements = append(parent.Elements, m.M(top.TagName, top.Elements...))
+	}
+	for i := 0; i < len(ops); i++ {
+		top := stack[len(stack)-1]
+		switch ops[i] % 4 {
+		case 0:
+			tagName := fuzzTags[int(ops[i]/4)%len(fuzzTags)]
+			if isVoid(tagName) {
+				top.Elements = append(top.Elements, m.M(tagName, m.Attr("title", value)))
+			} else {
+				stack = append(stack, &frame{TagName: tagName, Keys: map[string]bool{}})
+			}
+		case 1:
+			if len(stack) > 1 {
+				end()
+			}
+		case 2:
+			top.Elements = append(top.Elements, m.T(text))
+		case 3:
+			// Attributes must precede children, and the HTML parser ignores
+			// duplicates.
+			key := fuzzAttributes[int(ops[i]/4)%len(fuzzAttributes)]
+			if !top.Keys[key] && len(top.Elements) == len(top.Keys) {
+				top.Keys[key] = true
+				top.Elements = append(top.Elements, m.Attr(key, value))
+			}
+		}
+	}
+	for len(stack) > 1 {
+		end()
+	}
+	return m.M("div", stack[0].Elements...)
+}
+
+// isVoid reports whether tagName is a void element.
+func isVoid(tagName string) bool {
+	nodes, _ := m.Nodes(m.M(tagName))
+	return nodes[0].Void()
+}
+
+// writeTree writes a canonical representation of the expanded nodes, so
+// that they can be compared to those parsed by the HTML parser. Newlines are
+// normalized as by the parser.
+func writeTree(b *strings.Builder, nodes []*m.Node) {
+	for _, n := range nodes {
+		switch n.Type {
+		case m.TextNode:
+			b.WriteString(strconv.Quote(normalizeInput(n.Data)))
+		case m.ElementNode:
+			b.WriteString("<" + n.TagName)
+			for _, attr := range n.Attributes {
+				b.WriteString(" " + attr.Key + "=" + strconv.Quote(normalizeInput(attr.Value)))
+			}
+			b.WriteString(">")
+			writeTree(b, n.Children)
+			b.WriteString("</" + n.TagName + ">")
+		}
+	}
+}
+
+// fromParsed converts the nodes parsed by the HTML parser to Nodes.
+func fromParsed(n *nethtml.Node) []*m.Node {
+	var nodes []*m.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case nethtml.TextNode:
+			// Adjacent text is merged, as it is by m.Nodes.
+			if last := len(nodes) - 1; last >= 0 && nodes[last].Type == m.TextNode {
+				nodes[last].Data += c.Data
+			} else {
+				nodes = append(nodes, &m.Node{Type: m.TextNode, Data: c.Data})
+			}
+		case nethtml.ElementNode:
+			node := &m.Node{
+				Type:     m.ElementNode,
+				TagName:  c.Data,
+				Children: fromParsed(c),
+			}
+			for _, attr := range c.Attr {
+				node.Attributes = append(node.Attributes, m.Attribute{Key: attr.Key, Value: attr.Val})
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// normalizeInput returns s as it is read by an HTML parser, which
+// normalizes newlines.
+func normalizeInput(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+func FuzzRender(f *testing.F) {
+	f.Add("\x00\x03\x02\x01\x04\x02\x1c", "Hello, <World> & \"friends\"", `a "quoted" 'value' & <tag>`)
+	f.Add("\x08\x08\x02\x01\x02\x18", "", "")
+	f.Fuzz(func(t *testing.T, ops, text, value string) {
+		if strings.ContainsRune(text+value, 0) {
+			// The HTML parser drops or replaces NUL characters.
+			return
+		}
+		el := fuzzElement(ops, text, value)
+		output := m.RenderString(el)
+
+		nodes, err := m.Nodes(el)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected strings.Builder
+		writeTree(&expected, nodes)
+
+		parsed, err := nethtml.ParseFragment(strings.NewReader(output), &nethtml.Node{
+			Type:     nethtml.ElementNode,
+			Data:     "body",
+			DataAtom: atom.Body,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual strings.Builder
+		writeTree(&actual, fromParsed(&nethtml.Node{FirstChild: parsed[0]}))
+
+		if expected.String() != actual.String() {
+			t.Fatalf("%s\nparsed as:\n%s\nexpected:\n%s", output, actual.String(), expected.String())
+		}
+	})
+}
+
+func FuzzEscape(f *testing.F) {
+	f.Add("</div><script>alert(1)</script>", `" onclick="alert(1)`)
+	f.Add("</textarea></title></script></style>", "'><b>")
+	f.Add("&amp; &lt;!-- <![CDATA[", "&quot;")
+	f.Fuzz(func(t *testing.T, text, value string) {
+		if strings.ContainsRune(text, 0) {
+			// The HTML tokenizer replaces NUL characters in some elements.
+			return
+		}
+		for _, tagName := range []string{"div", "textarea", "title", "script", "style"} {
+			output := m.RenderString(m.M(tagName, m.Attr("title", value), m.T(text)))
+			z := nethtml.NewTokenizer(strings.NewReader(output))
+
+			// The element must be a start tag with a single attribute, text
+			// and an end tag.
+			token := z.Next()
+			tok := z.Token()
+			if token != nethtml.StartTagToken || tok.Data != tagName || len(tok.Attr) != 1 || tok.Attr[0].Key != "title" {
+				t.Fatalf("%s: unexpected start tag %s", output, tok)
+			}
+			if expected := normalizeInput(strings.ReplaceAll(value, "\x00", "\uFFFD")); tok.Attr[0].Val != expected {
+				t.Fatalf("%s: got attribute %q; expected %q", output, tok.Attr[0].Val, expected)
+			}
+			var actual strings.Builder
+			for token = z.Next(); token == nethtml.TextToken; token = z.Next() {
+				actual.Write(z.Text())
+			}
+			if tok := z.Token(); token != nethtml.EndTagToken || tok.Data != tagName {
+				t.Fatalf("%s: unexpected end tag %s", output, tok)
+			}
+			if token := z.Next(); token != nethtml.ErrorToken {
+				t.Fatalf("%s: unexpected token after end tag %s", output, z.Token())
+			}
+
+			expected := text
+			if tagName == "script" || tagName == "style" {
+				// Raw text is not unescaped.
+				expected = html.EscapeString(text)
+			}
+			if expected = normalizeInput(expected); actual.String() != expected {
+				t.Fatalf("%s: got text %q; expected %q", output, actual.String(), expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,13 @@
+// The fuzz tests only use the exported API of layeh.com/m, which they
+// require at a tagged version. To test a checkout, use a workspace, which is
+// not committed:
+//
+//	go work init . ./internal/fuzz
+module layeh.com/m/internal/fuzz
+
+go 1.23.0
+
+require (
+	golang.org/x/net v0.35.0
+	layeh.com/m v0.1.0
+)
@@ -0,0 +1,205 @@
+// Package lines writes text as prefixed lines separated by blocks, which is
+// shared by the plain text and Markdown renderers.
+package lines
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Blocks is the number of line breaks around block elements: 2 for elements
+// that are separated by a blank line, and 1 otherwise.
+var Blocks = map[string]int{
+	"address":    2,
+	"article":    1,
+	"aside":      1,
+	"blockquote": 2,
+	"dd":         1,
+	"details":    1,
+	"div":        1,
+	"dl":         2,
+	"dt":         1,
+	"fieldset":   1,
+	"figcaption": 1,
+	"figure":     2,
+	"footer":     1,
+	"form":       1,
+	"h1":         2,
+	"h2":         2,
+	"h3":         2,
+	"h4":         2,
+	"h5":         2,
+	"h6":         2,
+	"header":     1,
+	"hr":         2,
+	"li":         1,
+	"main":       1,
+	"nav":        1,
+	"ol":         2,
+	"p":          2,
+	"pre":        2,
+	"section":    1,
+	"summary":    1,
+	"table":      2,
+	"tr":         1,
+	"ul":         2,
+}
+
+// BlockBreaks returns Blocks[tagName], except that lists inside of list
+// items are not separated by blank lines.
+func BlockBreaks(tagName string, inList bool) int {
+	if (tagName == "ul" || tagName == "ol") && inList {
+		return 1
+	}
+	return Blocks[tagName]
+}
+
+// Skipped are the elements whose contents are not text.
+var Skipped = map[string]bool{
+	"head":     true,
+	"noscript": true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"title":    true,
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// RawText returns the text of raw HTML, whose tags are replaced with spaces
+// and whose character references are decoded.
+func RawText(raw string) string {
+	return html.UnescapeString(tagPattern.ReplaceAllString(raw, " "))
+}
+
+// Writer writes lines of text. Line breaks and whitespace are pending until
+// the next text is written, so that they are not written at the end.
+type Writer struct {
+	B bytes.Buffer
+
+	// Breaks is the number of pending line breaks, and BreakPrefix is the
+	// prefix when they were requested. Space is whether there is pending
+	// whitespace, and LineBreak is whether there is a pending line break
+	// that is preceded by HardBreak.
+	Breaks      int
+	BreakPrefix string
+	Space       bool
+	LineBreak   bool
+	HardBreak   string
+
+	// Prefix is written at the start of each line, and Marker replaces the
+	// prefix of the next line (e.g. a list bullet).
+	Prefix string
+	Marker string
+
+	// Inline is whether only a single line can be written, such as in a
+	// link or table cell. Blocks are separated by spaces.
+	Inline bool
+}
+
+// Block requests n line breaks before the next text.
+func (w *Writer) Block(n int) {
+	if w.Inline {
+		if n > 0 {
+			w.Space = true
+		}
+		return
+	}
+	if w.Breaks == 0 {
+		w.BreakPrefix = w.Prefix
+	}
+	if n > w.Breaks {
+		w.Breaks = n
+	}
+}
+
+// AtLineStart reports whether the next text starts a line.
+func (w *Writer) AtLineStart() bool {
+	return !w.Inline && (w.B.Len() == 0 || w.Breaks > 0 || w.LineBreak)
+}
+
+// StartLine writes the marker or prefix of a line.
+func (w *Writer) StartLine() {
+	if w.Marker != "" {
+		w.B.WriteString(w.Marker)
+		w.Marker = ""
+	} else {
+		w.B.WriteString(w.Prefix)
+	}
+}
+
+// Flush writes the pending line breaks and whitespace.
+func (w *Writer) Flush() {
+	switch {
+	case w.Inline:
+		if w.Space {
+			w.B.WriteByte(' ')
+		}
+	case w.B.Len() == 0:
+		w.StartLine()
+	case w.Breaks > 0:
+		// Blank lines are only prefixed by what is common to the
+		// surrounding lines (e.g. inside of the same blockquote).
+		blank := strings.TrimRight(commonPrefix(w.BreakPrefix, w.Prefix), " ")
+		for i := 0; i < w.Breaks; i++ {
+			if i > 0 {
+				w.B.WriteString(blank)
+			}
+			w.B.WriteByte('\n')
+		}
+		w.StartLine()
+	case w.LineBreak:
+		w.B.WriteString(w.HardBreak)
+		w.B.WriteByte('\n')
+		w.StartLine()
+	case w.Space:
+		w.B.WriteByte(' ')
+	}
+	w.Breaks, w.Space, w.LineBreak = 0, false, false
+}
+
+// Write writes s verbatim, which must not contain line breaks.
+func (w *Writer) Write(s string) {
+	if s == "" {
+		return
+	}
+	w.Flush()
+	w.B.WriteString(s)
+}
+
+// Words writes the words of s, with whitespace collapsed to pending
+// spaces, including whitespace at the start and end of s. escape, if not
+// nil, returns the text of each word, which is called just before the word
+// is written.
+func (w *Writer) Words(s string, escape func(word string) string) {
+	if s != "" && isSpace(s[0]) {
+		w.Space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			w.Space = true
+		}
+		if escape != nil {
+			word = escape(word)
+		}
+		w.Write(word)
+	}
+	if s != "" && isSpace(s[len(s)-1]) {
+		w.Space = true
+	}
+}
+
+// isSpace reports whether c is ASCII whitespace, as in strings.Fields.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
@@ -0,0 +1,63 @@
+package lines
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var w Writer
+	w.HardBreak = `\`
+	w.Block(2)
+	w.Write("a")
+	w.Space = true
+	w.Write("b")
+	w.Block(2)
+	w.Prefix = "> "
+	w.Write("c")
+	w.Block(2)
+	w.Marker = "> - "
+	w.Prefix = ">   "
+	w.Write("d")
+	w.LineBreak = true
+	w.Write("e")
+	w.Block(1)
+	w.Space = true
+
+	if output, expected := w.B.String(), "a b\n\n> c\n>\n> - d\\\n>   e"; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+
+	inline := Writer{Inline: true}
+	inline.Write("a")
+	inline.Block(2)
+	inline.Write("b")
+	if output, expected := inline.B.String(), "a b"; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+}
+
+func TestWriter_Words(t *testing.T) {
+	var w Writer
+	w.Write("a")
+	w.Words("\vb  c\t", strings.ToUpper)
+	w.Words("d", nil)
+	if output, expected := w.B.String(), "a B C d"; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+}
+
+func TestRawText(t *testing.T) {
+	tests := []struct {
+		Raw, Expected string
+	}{
+		{"a<br>b", "a b"},
+		{"<p class=\"x\">&lt;tag&gt; &amp;&nbsp;x</p>", " <tag> &\u00a0x "},
+		{"no tags", "no tags"},
+	}
+	for _, tt := range tests {
+		if got := RawText(tt.Raw); got != tt.Expected {
+			t.Errorf("RawText(%q): got %#v; expected %#v", tt.Raw, got, tt.Expected)
+		}
+	}
+}
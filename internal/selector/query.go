@@ -0,0 +1,175 @@
+package selector
+
+import (
+	"strings"
+)
+
+// Combinator is the relationship between a compound selector and the one
+// preceding it.
+type Combinator byte
+
+// Combinators.
+const (
+	Descendant Combinator = ' '
+	Child      Combinator = '>'
+)
+
+// AttrMatcher is an attribute condition of a compound selector.
+//
+// Op is 0 when the attribute only needs to exist. Otherwise, it is one of
+// '=', '~', '|', '^', '$', '*', as in the CSS attribute selectors [k=v],
+// [k~=v], [k|=v], [k^=v], [k$=v] and [k*=v].
+type AttrMatcher struct {
+	Key   string
+	Op    byte
+	Value string
+}
+
+// attrOperators are the operators of AttrMatcher, other than =.
+const attrOperators = "~|^$*"
+
+// Compound is a compound selector of a query (e.g. li.active[title]).
+type Compound struct {
+	// Combinator is the relationship to the previous compound selector. It
+	// is zero for the first compound selector of a Complex.
+	Combinator Combinator
+
+	TagName    string
+	ID         string
+	Classes    []string
+	Attributes []AttrMatcher
+}
+
+// Complex is a sequence of compound selectors separated by combinators
+// (e.g. ul > li.active a).
+type Complex []Compound
+
+// ParseQuery parses a comma-separated list of complex CSS selectors.
+//
+// Each compound selector uses the same syntax as Parse, with the addition
+// of the attribute operators described by AttrMatcher.
+func ParseQuery(s string) ([]Complex, error) {
+	var list []Complex
+	var complex Complex
+	combinator := Combinator(0)
+
+	// flush adds the compound selector part, which starts at offset start.
+	flush := func(part string, start int) error {
+		if part == "" {
+			return nil
+		}
+		if len(complex) > 0 && combinator == 0 {
+			combinator = Descendant
+		}
+		compound, err := parse(part, true)
+		if err != nil {
+			return newError(err.Err, s, start+err.Offset)
+		}
+		compound.Combinator = combinator
+		complex = append(complex, compound)
+		combinator = 0
+		return nil
+	}
+
+	var part strings.Builder
+	var sc scanner
+	start := 0
+	for i, ch := range s {
+		if sc.next(ch) && (isSpace(ch) || ch == '>' || ch == ',') {
+			if err := flush(part.String(), start); err != nil {
+				return nil, err
+			}
+			part.Reset()
+			start = i + 1
+			switch ch {
+			case '>':
+				if combinator != 0 || len(complex) == 0 {
+					return nil, newError(ErrInvalid, s, i)
+				}
+				combinator = Child
+			case ',':
+				if combinator != 0 || len(complex) == 0 {
+					return nil, newError(ErrInvalid, s, i)
+				}
+				list = append(list, complex)
+				complex = nil
+			}
+			continue
+		}
+		part.WriteRune(ch)
+	}
+	if err := flush(part.String(), start); err != nil {
+		return nil, err
+	}
+	if combinator != 0 || len(complex) == 0 {
+		return nil, newError(ErrInvalid, s, len(s))
+	}
+	return append(list, complex), nil
+}
+
+type scannerState int
+
+const (
+	stateOutside scannerState = iota
+	stateKey
+	stateValueStart
+	stateValue
+	stateQuoted
+)
+
+// scanner tracks whether a position of a selector is inside of an attribute
+// or escaped.
+type scanner struct {
+	state   scannerState
+	quote   rune
+	escaped bool
+}
+
+// next advances the scanner past ch, and reports whether ch is outside of
+// an attribute and not escaped.
+func (sc *scanner) next(ch rune) bool {
+	if sc.escaped {
+		sc.escaped = false
+		return false
+	}
+	if ch == '\\' {
+		sc.escaped = true
+		if sc.state == stateValueStart {
+			sc.state = stateValue
+		}
+		return false
+	}
+	switch sc.state {
+	case stateOutside:
+		if ch == '[' {
+			sc.state = stateKey
+			return false
+		}
+		return true
+	case stateKey:
+		switch ch {
+		case '=':
+			sc.state = stateValueStart
+		case ']':
+			sc.state = stateOutside
+		}
+	case stateValueStart:
+		switch {
+		case ch == '\'' || ch == '"':
+			sc.state, sc.quote = stateQuoted, ch
+		case ch == ']':
+			sc.state = stateOutside
+		case !isSpace(ch):
+			sc.state = stateValue
+		}
+	case stateValue:
+		if ch == ']' {
+			sc.state = stateOutside
+		}
+	case stateQuoted:
+		if ch == sc.quote {
+			sc.state = stateValue
+		}
+	}
+	return false
+}
@@ -2,8 +2,8 @@ package selector
 
 import (
 	"errors"
-	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 type Result struct {
@@ -13,40 +13,56 @@ type Result struct {
 	Attributes [][2]string
 }
 
-func valueNeedsEscaped(s string) bool {
-	return strings.IndexAny(s, "']") != -1
+// escapeIdent escapes the characters of s that are in special or are a
+// backslash.
+func escapeIdent(s, special string) string {
+	if !strings.ContainsAny(s, special+`\`) {
+		return s
+	}
+	var b strings.Builder
+	for _, ch := range s {
+		if ch == '\\' || strings.ContainsRune(special, ch) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
 }
 
+// valueNeedsQuoted reports whether the attribute value s must be quoted to
+// be parsed back.
+func valueNeedsQuoted(s string) bool {
+	if strings.ContainsAny(s, `'"]\`) {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(s)
+	last, _ := utf8.DecodeLastRuneInString(s)
+	return isSpace(first) || isSpace(last)
+}
+
+// String returns a selector that parses to r.
 func (r *Result) String() string {
 	if r == nil {
 		return "<nil>"
 	}
 	var b strings.Builder
-	b.WriteString(r.TagName)
+	b.WriteString(escapeIdent(r.TagName, "#.["))
 	if r.ID != "" {
 		b.WriteByte('#')
-		b.WriteString(r.ID)
+		b.WriteString(escapeIdent(r.ID, "#.["))
 	}
 	for _, class := range r.Classes {
 		b.WriteByte('.')
-		b.WriteString(class)
+		b.WriteString(escapeIdent(class, "#.["))
 	}
 	for _, attr := range r.Attributes {
 		b.WriteByte('[')
-		b.WriteString(attr[0])
+		b.WriteString(escapeIdent(attr[0], "=] \t\n\r\f"))
 		if attr[1] != "" {
 			b.WriteByte('=')
-			if valueNeedsEscaped(attr[1]) {
+			if valueNeedsQuoted(attr[1]) {
 				b.WriteByte('\'')
-				for _, ch := range attr[1] {
-					switch ch {
-					case '\'':
-						b.WriteByte('\\')
-						b.WriteRune(ch)
-					default:
-						b.WriteRune(ch)
-					}
-				}
+				b.WriteString(escapeIdent(attr[1], "'"))
 				b.WriteByte('\'')
 			} else {
 				b.WriteString(attr[1])
@@ -60,12 +76,15 @@ func (r *Result) String() string {
 type Error struct {
 	Err      error
 	Selector string
+	// Offset is the byte offset of Selector where the error was found.
+	Offset int
 }
 
-func newError(underlying error, selector string) *Error {
+func newError(underlying error, selector string, offset int) *Error {
 	return &Error{
 		Err:      underlying,
 		Selector: selector,
+		Offset:   offset,
 	}
 }
 
@@ -83,129 +102,210 @@ var (
 	ErrInvalidAttr  = errors.New("invalid attribute")
 )
 
+// Parse parses a selector of the form tagname#id.class[key=value].
+//
+// Any number of IDs and classes can follow the tag name, of which the last
+// ID is used, and are followed by the attributes. Attribute values can be
+// unquoted or quoted with ' or ", and whitespace is allowed around the key
+// and value. A backslash escapes any character, such as a . in a class or a
+// quote in a quoted value.
 func Parse(s string) (*Result, error) {
-	if s == "" {
-		return &Result{}, nil
+	compound, err := parse(s, false)
+	if err != nil {
+		return nil, err
 	}
+	result := &Result{
+		TagName: compound.TagName,
+		ID:      compound.ID,
+		Classes: compound.Classes,
+	}
+	for _, attr := range compound.Attributes {
+		result.Attributes = append(result.Attributes, [2]string{attr.Key, attr.Value})
+	}
+	return result, nil
+}
 
-	var r strings.Reader
-	r.Reset(s)
-
-	result := new(Result)
-	result.TagName = nextID(&r, "#.[")
+// parse parses s as a compound selector. If query is true, attributes can
+// have the operators of AttrMatcher.
+func parse(s string, query bool) (Compound, *Error) {
+	var c Compound
+	p := parser{s: s}
 
-	if ch, _, _ := r.ReadRune(); ch == '#' {
-		result.ID = nextID(&r, "#.[")
-		if result.ID == "" {
-			return nil, newError(ErrInvalidID, s)
-		}
-	} else {
-		r.UnreadRune()
+	tagName, ok := p.ident("#.[", false)
+	if !ok {
+		return Compound{}, newError(ErrInvalid, s, p.pos)
 	}
+	c.TagName = tagName
 
 	for {
-		if ch, _, _ := r.ReadRune(); ch == '.' {
-			class := nextID(&r, "#.[")
-			if class == "" {
-				return nil, newError(ErrInvalidClass, s)
+		start := p.pos
+		if ch := p.peek(); ch == '#' {
+			p.pos++
+			id, ok := p.ident("#.[", false)
+			if !ok || id == "" {
+				return Compound{}, newError(ErrInvalidID, s, start)
+			}
+			c.ID = id
+		} else if ch == '.' {
+			p.pos++
+			class, ok := p.ident("#.[", false)
+			if !ok || class == "" {
+				return Compound{}, newError(ErrInvalidClass, s, start)
 			}
-			result.Classes = append(result.Classes, class)
+			c.Classes = append(c.Classes, class)
 		} else {
-			r.UnreadRune()
 			break
 		}
 	}
 
-	for {
-		if ch, _, _ := r.ReadRune(); ch == '[' {
-			var attrKey, attrValue string
-			attrKey = nextID(&r, "=]")
-			if attrKey == "" || r.Len() == 0 {
-				return nil, newError(ErrInvalidAttr, s)
-			}
-			if ch, _, _ := r.ReadRune(); ch == ']' {
-				// empty attribute value
-			} else if ch == '=' {
-
-				if ch, _, _ := r.ReadRune(); ch == '\'' {
-					attrValue = nextQuotedValue(&r)
-				} else {
-					r.UnreadRune()
-					attrValue = nextUnquotedValue(&r)
-				}
-
-				if ch, _, err := r.ReadRune(); err != nil || ch != ']' {
-					return nil, newError(ErrInvalidAttr, s)
-				}
-			} else {
-				return nil, newError(ErrInvalidAttr, s)
-			}
-			result.Attributes = append(result.Attributes, [2]string{attrKey, attrValue})
-		} else {
-			r.UnreadRune()
-			break
+	for p.peek() == '[' {
+		start := p.pos
+		attr, ok := p.attribute(query)
+		if !ok {
+			return Compound{}, newError(ErrInvalidAttr, s, start)
 		}
+		c.Attributes = append(c.Attributes, attr)
 	}
 
-	if r.Len() != 0 {
-		return nil, newError(ErrInvalid, s)
+	if p.pos != len(s) {
+		return Compound{}, newError(ErrInvalid, s, p.pos)
 	}
+	return c, nil
+}
 
-	return result, nil
+type parser struct {
+	s   string
+	pos int
+}
+
+// peek returns the next character, or -1 at the end of the selector.
+func (p *parser) peek() rune {
+	ch, _ := p.decode()
+	return ch
+}
+
+func (p *parser) next() rune {
+	ch, size := p.decode()
+	p.pos += size
+	return ch
 }
 
-func nextID(r *strings.Reader, exceptCharset string) string {
-	if len(exceptCharset) == 0 {
-		panic("empty exceptCharset")
+func (p *parser) decode() (rune, int) {
+	if p.pos >= len(p.s) {
+		return -1, 0
 	}
+	return utf8.DecodeRuneInString(p.s[p.pos:])
+}
 
-	var s strings.Builder
-	for {
-		ch, _, err := r.ReadRune()
-		if err == io.EOF || strings.ContainsAny(string(ch), exceptCharset) {
-			r.UnreadRune()
-			break
-		}
-		s.WriteRune(ch)
+func (p *parser) skipSpace() {
+	for isSpace(p.peek()) {
+		p.next()
+	}
+}
+
+func isSpace(ch rune) bool {
+	switch ch {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
 	}
-	return s.String()
+	return false
 }
 
-func nextQuotedValue(r *strings.Reader) string {
+// ident reads until one of the characters of stop, or whitespace if space
+// is true, and resolves escapes. It returns false if the selector ends with
+// a backslash.
+func (p *parser) ident(stop string, space bool) (string, bool) {
 	var b strings.Builder
 	for {
-		ch, _, err := r.ReadRune()
-		if err == io.EOF {
-			return b.String()
+		ch := p.peek()
+		if ch == -1 || strings.ContainsRune(stop, ch) || space && isSpace(ch) {
+			return b.String(), true
 		}
-		switch ch {
-		case '\\':
-			if next, _, _ := r.ReadRune(); next == '\'' {
-				b.WriteRune(next)
-			} else {
-				r.UnreadRune()
-				return b.String()
+		p.next()
+		if ch == '\\' {
+			if ch = p.next(); ch == -1 {
+				return "", false
 			}
-		case '\'':
-			return b.String()
-		default:
-			b.WriteRune(ch)
 		}
+		b.WriteRune(ch)
+	}
+}
+
+// attribute reads an attribute, starting at its [.
+func (p *parser) attribute(query bool) (AttrMatcher, bool) {
+	var attr AttrMatcher
+	p.next()
+	p.skipSpace()
+
+	stop := "=]"
+	if query {
+		stop += attrOperators
+	}
+	key, ok := p.ident(stop, true)
+	if !ok || key == "" {
+		return attr, false
+	}
+	attr.Key = key
+	p.skipSpace()
+	if ch := p.peek(); query && strings.ContainsRune(attrOperators, ch) {
+		p.next()
+		if p.peek() != '=' {
+			return attr, false
+		}
+		attr.Op = byte(ch)
+	}
+
+	switch p.next() {
+	case ']':
+		return attr, true
+	case '=':
+		if attr.Op == 0 {
+			attr.Op = '='
+		}
+	default:
+		return attr, false
+	}
+
+	p.skipSpace()
+	if quote := p.peek(); quote == '\'' || quote == '"' {
+		p.next()
+		value, ok := p.ident(string(quote), false)
+		if !ok || p.next() != quote {
+			return attr, false
+		}
+		attr.Value = value
+	} else {
+		value, ok := p.unquotedValue()
+		if !ok {
+			return attr, false
+		}
+		attr.Value = value
 	}
+	p.skipSpace()
+	return attr, p.next() == ']'
 }
 
-func nextUnquotedValue(r *strings.Reader) string {
+// unquotedValue reads an unquoted attribute value, without its trailing
+// whitespace unless it is escaped.
+func (p *parser) unquotedValue() (string, bool) {
 	var b strings.Builder
+	end := 0
 	for {
-		ch, _, err := r.ReadRune()
-		if err == io.EOF {
+		ch := p.peek()
+		if ch == -1 || ch == ']' {
 			break
 		}
-		if ch == ']' {
-			r.UnreadRune()
-			break
+		p.next()
+		escaped := ch == '\\'
+		if escaped {
+			if ch = p.next(); ch == -1 {
+				return "", false
+			}
 		}
 		b.WriteRune(ch)
+		if escaped || !isSpace(ch) {
+			end = b.Len()
+		}
 	}
-	return b.String()
+	return b.String()[:end], true
 }
@@ -10,23 +10,25 @@ func TestParse(t *testing.T) {
 		Input  string
 		Error  bool
 		Result Result
+		// String is the expected Result.String, if it differs from Input.
+		String string
 	}{
 		{
-			"",
-			false,
-			Result{},
+			Input:  "",
+			Error:  false,
+			Result: Result{},
 		},
 		{
-			"div",
-			false,
-			Result{
+			Input: "div",
+			Error: false,
+			Result: Result{
 				TagName: "div",
 			},
 		},
 		{
-			"#hello",
-			false,
-			Result{
+			Input: "#hello",
+			Error: false,
+			Result: Result{
 				TagName: "",
 				ID:      "hello",
 			},
@@ -106,19 +108,115 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			Input: "#a.b#c",
+			Error: false,
+			Result: Result{
+				ID:      "c",
+				Classes: []string{"b"},
+			},
+			String: "#c.b",
+		},
+		{
+			Input: `[title="Hello world"]`,
+			Error: false,
+			Result: Result{
+				Attributes: [][2]string{
+					{"title", "Hello world"},
+				},
+			},
+			String: "[title=Hello world]",
+		},
+		{
+			Input: `[ title = "a \"b\" 'c'" ][ checked ][ x= y z ]`,
+			Error: false,
+			Result: Result{
+				Attributes: [][2]string{
+					{"title", `a "b" 'c'`},
+					{"checked", ""},
+					{"x", "y z"},
+				},
+			},
+			String: `[title='a "b" \'c\''][checked][x=y z]`,
+		},
+		{
+			Input: `[a=' b '][b=c\ ][c=\]][d='\\']`,
+			Error: false,
+			Result: Result{
+				Attributes: [][2]string{
+					{"a", " b "},
+					{"b", "c "},
+					{"c", "]"},
+					{"d", `\`},
+				},
+			},
+			String: `[a=' b '][b='c '][c=']'][d='\\']`,
+		},
+		{
+			Input: `my\#tag#a\.b.c\[d\]\\[data\=x\ y=\z]`,
+			Error: false,
+			Result: Result{
+				TagName: "my#tag",
+				ID:      "a.b",
+				Classes: []string{`c[d]\`},
+				Attributes: [][2]string{
+					{"data=x y", "z"},
+				},
+			},
+			String: `my\#tag#a\.b.c\[d]\\[data\=x\ y=z]`,
+		},
+		{
+			Input: "p[title=日本語]",
+			Error: false,
+			Result: Result{
+				TagName: "p",
+				Attributes: [][2]string{
+					{"title", "日本語"},
+				},
+			},
+		},
+		{Input: "#", Error: true},
+		{Input: "div#", Error: true},
+		{Input: "div.", Error: true},
+		{Input: "div..a", Error: true},
+		{Input: "div[", Error: true},
+		{Input: "div[]", Error: true},
+		{Input: "div[ ]", Error: true},
+		{Input: "div[a", Error: true},
+		{Input: "div[a b]", Error: true},
+		{Input: "div[a=b", Error: true},
+		{Input: "div[a='b]", Error: true},
+		{Input: `div[a="b']`, Error: true},
+		{Input: "div[a='b'c]", Error: true},
+		{Input: "div[a]b", Error: true},
+		{Input: "div[a].b", Error: true},
+		{Input: `div\`, Error: true},
+		{Input: `div.a\`, Error: true},
+		{Input: `div[a=\`, Error: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.Input, func(t *testing.T) {
 			result, err := Parse(tt.Input)
-			if err == nil && !tt.Error && !reflect.DeepEqual(*result, tt.Result) {
-				t.Errorf("got %#v; expected %#v", *result, tt.Result)
-			} else if err == nil && !tt.Error && reflect.DeepEqual(*result, tt.Result) && result.String() != tt.Input {
-				t.Errorf("got String() = %#v; expected %#v", result.String(), tt.Input)
-			} else if err == nil && tt.Error {
+			if err != nil {
+				if !tt.Error {
+					t.Errorf("got error: %s", err)
+				}
+				return
+			}
+			expected := tt.String
+			if expected == "" {
+				expected = tt.Input
+			}
+			if tt.Error {
 				t.Errorf("no error, but expected one")
-			} else if err != nil && !tt.Error {
-				t.Errorf("got error: %s", err)
+			} else if !reflect.DeepEqual(*result, tt.Result) {
+				t.Errorf("got %#v; expected %#v", *result, tt.Result)
+			} else if result.String() != expected {
+				t.Errorf("got String() = %#v; expected %#v", result.String(), expected)
+			} else if parsed, err := Parse(result.String()); err != nil || !reflect.DeepEqual(parsed, result) {
+				t.Errorf("String() = %#v does not round-trip: got (%#v, %v)", result.String(), parsed, err)
 			}
 		})
 	}
@@ -130,3 +228,173 @@ func TestResult_String(t *testing.T) {
 		t.Fatalf("got %#v; expected %#v", str, expected)
 	}
 }
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Error  bool
+		Result []Complex
+	}{
+		{
+			Input: "li",
+			Result: []Complex{
+				{{TagName: "li"}},
+			},
+		},
+		{
+			Input: "ul > li.active",
+			Result: []Complex{
+				{
+					{TagName: "ul"},
+					{Combinator: Child, TagName: "li", Classes: []string{"active"}},
+				},
+			},
+		},
+		{
+			Input: "ul>li a",
+			Result: []Complex{
+				{
+					{TagName: "ul"},
+					{Combinator: Child, TagName: "li"},
+					{Combinator: Descendant, TagName: "a"},
+				},
+			},
+		},
+		{
+			Input: "ul\r\nli\fa",
+			Result: []Complex{
+				{
+					{TagName: "ul"},
+					{Combinator: Descendant, TagName: "li"},
+					{Combinator: Descendant, TagName: "a"},
+				},
+			},
+		},
+		{
+			Input: "h1, h2",
+			Result: []Complex{
+				{{TagName: "h1"}},
+				{{TagName: "h2"}},
+			},
+		},
+		{
+			Input: "a[href^=https][title='a > b, c'][download][data-x=]",
+			Result: []Complex{
+				{
+					{
+						TagName: "a",
+						Attributes: []AttrMatcher{
+							{Key: "href", Op: '^', Value: "https"},
+							{Key: "title", Op: '=', Value: "a > b, c"},
+							{Key: "download"},
+							{Key: "data-x", Op: '='},
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `a[ title *= "x ] y" ] , p\.b[ data-x = '>, ' ]`,
+			Result: []Complex{
+				{
+					{
+						TagName: "a",
+						Attributes: []AttrMatcher{
+							{Key: "title", Op: '*', Value: "x ] y"},
+						},
+					},
+				},
+				{
+					{
+						TagName: "p.b",
+						Attributes: []AttrMatcher{
+							{Key: "data-x", Op: '=', Value: ">, "},
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `ul\ li > a[title=\]\ ]`,
+			Result: []Complex{
+				{
+					{TagName: "ul li"},
+					{
+						Combinator: Child,
+						TagName:    "a",
+						Attributes: []AttrMatcher{
+							{Key: "title", Op: '=', Value: "] "},
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: "",
+			Error: true,
+		},
+		{
+			Input: "a[title~x]",
+			Error: true,
+		},
+		{
+			Input: "> li",
+			Error: true,
+		},
+		{
+			Input: "ul >",
+			Error: true,
+		},
+		{
+			Input: "ul,,li",
+			Error: true,
+		},
+		{
+			Input: "li#",
+			Error: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Input, func(t *testing.T) {
+			result, err := ParseQuery(tt.Input)
+			if err == nil && tt.Error {
+				t.Errorf("no error, but expected one")
+			} else if err != nil && !tt.Error {
+				t.Errorf("got error: %s", err)
+			} else if err == nil && !reflect.DeepEqual(result, tt.Result) {
+				t.Errorf("got %#v; expected %#v", result, tt.Result)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"a#main.a.b",
+		"p[title='value []'][data-x='escaped \\' quote']",
+		`[ title = "a \"b\" 'c'" ][ checked ][ x= y z ]`,
+		`my\#tag#a\.b.c\[d\]\\[data\=x\ y=\z]`,
+		"div[a='b]",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		result, err := Parse(s)
+		if err != nil {
+			return
+		}
+		str := result.String()
+		parsed, err := Parse(str)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %#v, but Parse(String() = %q): %s", s, result, str, err)
+		}
+		if !reflect.DeepEqual(parsed, result) {
+			t.Fatalf("Parse(%q) = %#v, but Parse(String() = %q) = %#v", s, result, str, parsed)
+		}
+		if parsed.String() != str {
+			t.Fatalf("String() = %q is not stable: got %q", str, parsed.String())
+		}
+	})
+}
@@ -0,0 +1,299 @@
+// Package live keeps a browser's DOM in sync with an element tree that is
+// rendered on the server.
+//
+// A Session holds the last element tree sent to a client. Each call to
+// Update returns a Patch that describes the changes since the previous
+// call, which is sent to the client over a server-sent event Stream. The
+// JavaScript in Script applies the patches to the children of a root DOM
+// element:
+//
+//	<div id="app"></div>
+//	<script src="/live.js"></script>
+//	<script>mLive(document.getElementById("app"), "/events");</script>
+//
+// The first patch of a Session removes the children of the root element
+// and inserts the whole tree. An EventSource reconnects on its own after the
+// connection drops, and the handler then starts a new Session, whose first
+// patch brings the client back in sync. Raw elements should each contain a single HTML
+// node; otherwise, the client wraps their contents in a span styled with
+// display: contents so that paths remain valid.
+package live
+
+import (
+	"bytes"
+	_ "embed" // for Script
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"layeh.com/m"
+	"layeh.com/m/diff"
+)
+
+// Script is the JavaScript that applies patches on the client. It defines
+// the global function mLive(root, url), which opens an EventSource to url
+// and applies each "patch" event to the children of root.
+//
+//go:embed live.js
+var Script string
+
+// ScriptHandler returns a handler that serves Script.
+func ScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		io.WriteString(w, Script)
+	})
+}
+
+// Session is the element tree most recently sent to a client.
+//
+// The zero value is a new session, whose client's tree is unknown. A
+// Session is safe for concurrent use.
+type Session struct {
+	mu      sync.Mutex
+	nodes   []*m.Node
+	started bool
+}
+
+// Update sets the session's tree to the expansion of element, and returns
+// the patch that transforms the previous tree into it. The first patch of a
+// session starts with a diff.Reset operation, which clears the client's
+// tree, such as the one of a previous connection.
+//
+// A non-nil error is returned if element could not be expanded, in which
+// case the session is unchanged.
+func (s *Session) Update(element m.Element) (Patch, error) {
+	nodes, err := m.Nodes(element)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var patch Patch
+	if !s.started {
+		patch = Patch{{Type: diff.Reset}}
+		s.started = true
+	}
+	patch = append(patch, diff.Nodes(s.nodes, nodes)...)
+	s.nodes = nodes
+	return patch, nil
+}
+
+// Patch is a list of operations that update a client's DOM.
+//
+// A Patch is encoded as a JSON array of operations. Each operation is an
+// array whose first two values are the diff.OpType and path of the
+// operation, followed by:
+//
+//	Insert, Replace: node
+//	SetAttr:         key, value
+//	RemoveAttr:      key
+//	SetText:         text
+//	Reset:           nothing, and the path is empty
+//
+// A text node is encoded as a string, a raw node as {"raw": html}, and an
+// element node as an array of its tag name, its attributes as an array of
+// alternating keys and values, and its children:
+//
+//	[1,[0,2],["li",["class","active"],"Home"]]
+type Patch []diff.Op
+
+// MarshalJSON implements json.Marshaler.
+func (p Patch) MarshalJSON() ([]byte, error) {
+	ops := make([]interface{}, len(p))
+	for i, op := range p {
+		path := op.Path
+		if path == nil {
+			path = []int{}
+		}
+		values := []interface{}{op.Type, path}
+		switch op.Type {
+		case diff.Insert, diff.Replace:
+			values = append(values, encodeNode(op.Node))
+		case diff.SetAttr:
+			values = append(values, op.Key, op.Value)
+		case diff.RemoveAttr:
+			values = append(values, op.Key)
+		case diff.SetText:
+			values = append(values, op.Value)
+		case diff.Remove, diff.Reset:
+		default:
+			return nil, fmt.Errorf("live: invalid operation type %s", op.Type)
+		}
+		ops[i] = values
+	}
+	return json.Marshal(ops)
+}
+
+type rawNode struct {
+	Raw string `json:"raw"`
+}
+
+func encodeNode(n *m.Node) interface{} {
+	switch n.Type {
+	case m.TextNode:
+		return n.Data
+	case m.RawNode:
+		return rawNode{n.Data}
+	}
+	attributes := make([]string, 0, len(n.Attributes)*2)
+	for _, attr := range n.Attributes {
+		attributes = append(attributes, attr.Key, attr.Value)
+	}
+	values := []interface{}{n.TagName, attributes}
+	for _, child := range n.Children {
+		values = append(values, encodeNode(child))
+	}
+	return values
+}
+
+var errInvalidPatch = errors.New("live: invalid patch")
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Patch) UnmarshalJSON(data []byte) error {
+	var ops [][]json.RawMessage
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return err
+	}
+	patch := make(Patch, len(ops))
+	for i, values := range ops {
+		if len(values) < 2 {
+			return errInvalidPatch
+		}
+		op := &patch[i]
+		if err := json.Unmarshal(values[0], &op.Type); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(values[1], &op.Path); err != nil {
+			return err
+		}
+		var args []*string
+		switch op.Type {
+		case diff.Insert, diff.Replace:
+			if len(values) != 3 {
+				return errInvalidPatch
+			}
+			var err error
+			if op.Node, err = decodeNode(values[2], m.HTMLNamespace); err != nil {
+				return err
+			}
+			continue
+		case diff.Remove, diff.Reset:
+		case diff.SetAttr:
+			args = []*string{&op.Key, &op.Value}
+		case diff.RemoveAttr:
+			args = []*string{&op.Key}
+		case diff.SetText:
+			args = []*string{&op.Value}
+		default:
+			return errInvalidPatch
+		}
+		if len(values) != 2+len(args) {
+			return errInvalidPatch
+		}
+		for j, arg := range args {
+			if err := json.Unmarshal(values[2+j], arg); err != nil {
+				return err
+			}
+		}
+	}
+	*p = patch
+	return nil
+}
+
+// decodeNode decodes a node whose parent is in the given namespace.
+// Namespaces are inferred in the same way as the client script.
+func decodeNode(data json.RawMessage, namespace string) (*m.Node, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return &m.Node{Type: m.TextNode, Data: text}, nil
+	}
+	var raw rawNode
+	if err := json.Unmarshal(data, &raw); err == nil {
+		return &m.Node{Type: m.RawNode, Data: raw.Raw}, nil
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if len(values) < 2 {
+		return nil, errInvalidPatch
+	}
+	n := &m.Node{Type: m.ElementNode}
+	if err := json.Unmarshal(values[0], &n.TagName); err != nil {
+		return nil, err
+	}
+	switch n.TagName {
+	case "svg":
+		n.Namespace = m.SVGNamespace
+	case "math":
+		n.Namespace = m.MathMLNamespace
+	default:
+		n.Namespace = namespace
+	}
+	childNamespace := n.Namespace
+	if n.TagName == "foreignObject" {
+		childNamespace = m.HTMLNamespace
+	}
+	var attributes []string
+	if err := json.Unmarshal(values[1], &attributes); err != nil {
+		return nil, err
+	}
+	if len(attributes)%2 != 0 {
+		return nil, errInvalidPatch
+	}
+	for i := 0; i < len(attributes); i += 2 {
+		n.Attributes = append(n.Attributes, m.Attribute{Key: attributes[i], Value: attributes[i+1]})
+	}
+	for _, value := range values[2:] {
+		child, err := decodeNode(value, childNamespace)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return n, nil
+}
+
+// Stream is a server-sent event stream of patches.
+type Stream struct {
+	w http.ResponseWriter
+}
+
+// NewStream sets the headers of a server-sent event response and returns a
+// Stream that writes to w.
+func NewStream(w http.ResponseWriter) *Stream {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	return &Stream{
+		w: w,
+	}
+}
+
+// Send writes patch as a "patch" event and flushes the response. Empty
+// patches are not sent.
+func (s *Stream) Send(patch Patch) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	b.WriteString("event: patch\ndata: ")
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(patch); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	if _, err := b.WriteTo(s.w); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
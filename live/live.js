@@ -0,0 +1,96 @@
+// mLive applies patches from the server-sent event stream at url to the
+// children of root. See layeh.com/m/live for the patch format.
+function mLive(root, url) {
+  "use strict";
+
+  var INSERT = 1, REMOVE = 2, REPLACE = 3, SET_ATTR = 4, REMOVE_ATTR = 5, SET_TEXT = 6, RESET = 7;
+  var SVG = "http://www.w3.org/2000/svg";
+  var MATHML = "http://www.w3.org/1998/Math/MathML";
+
+  function namespaceOf(tagName, parent) {
+    if (tagName === "svg") {
+      return SVG;
+    }
+    if (tagName === "math") {
+      return MATHML;
+    }
+    if (!parent || parent.localName === "foreignObject") {
+      return null;
+    }
+    var ns = parent.namespaceURI;
+    return ns === SVG || ns === MATHML ? ns : null;
+  }
+
+  function create(node, parent) {
+    if (typeof node === "string") {
+      return document.createTextNode(node);
+    }
+    if (!Array.isArray(node)) {
+      var template = document.createElement("template");
+      template.innerHTML = node.raw;
+      var content = template.content;
+      if (content.childNodes.length === 1) {
+        return content.firstChild;
+      }
+      var wrapper = document.createElement("span");
+      wrapper.style.display = "contents";
+      wrapper.appendChild(content);
+      return wrapper;
+    }
+    var ns = namespaceOf(node[0], parent);
+    var el = ns ? document.createElementNS(ns, node[0]) : document.createElement(node[0]);
+    var attributes = node[1];
+    for (var i = 0; i < attributes.length; i += 2) {
+      el.setAttribute(attributes[i], attributes[i + 1]);
+    }
+    for (var j = 2; j < node.length; j++) {
+      el.appendChild(create(node[j], el));
+    }
+    return el;
+  }
+
+  function apply(op) {
+    var type = op[0], path = op[1];
+    if (type === RESET) {
+      while (root.firstChild) {
+        root.removeChild(root.firstChild);
+      }
+      return;
+    }
+    var parent = root;
+    for (var i = 0; i < path.length - 1; i++) {
+      parent = parent.childNodes[path[i]];
+    }
+    var index = path[path.length - 1];
+    var target = parent.childNodes[index];
+    switch (type) {
+    case INSERT:
+      parent.insertBefore(create(op[2], parent === root ? null : parent), target || null);
+      break;
+    case REMOVE:
+      parent.removeChild(target);
+      break;
+    case REPLACE:
+      parent.replaceChild(create(op[2], parent === root ? null : parent), target);
+      break;
+    case SET_ATTR:
+      target.setAttribute(op[2], op[3]);
+      if (op[2] === "value" && "value" in target) {
+        target.value = op[3];
+      }
+      break;
+    case REMOVE_ATTR:
+      target.removeAttribute(op[2]);
+      break;
+    case SET_TEXT:
+      target.data = op[2];
+      break;
+    }
+  }
+
+  var source = new EventSource(url);
+  source.addEventListener("patch", function (event) {
+    JSON.parse(event.data).forEach(apply);
+  });
+  return source;
+}
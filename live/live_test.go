@@ -0,0 +1,182 @@
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"layeh.com/m"
+	"layeh.com/m/diff"
+)
+
+func counter(n int) m.Element {
+	return m.M("div.counter",
+		m.M("span", m.F("%d", n)),
+		m.If(n > 0, m.M("button[type=button]", m.T("Reset"))),
+		m.Range(n, func(i int) m.Element {
+			return m.M("i", m.Raw("&#9733;"))
+		}),
+	)
+}
+
+func TestPatch_MarshalJSON(t *testing.T) {
+	var s Session
+	patch, err := s.Update(m.M("ul", m.M("li.active", m.T("Home")), m.Raw("<hr>")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[[7,[]],[1,[0],["ul",[],["li",["class","active"],"Home"],{"raw":"\u003chr\u003e"}]]]`; string(data) != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	patch, err = s.Update(m.M("ul", m.M("li", m.T("Home!"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[[5,[0,0],"class"],[6,[0,0,0],"Home!"],[2,[0,1]]]`; string(data) != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	var decoded Patch
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(patch) {
+		t.Fatalf("got %d operations; expected %d", len(decoded), len(patch))
+	}
+	for i := range patch {
+		if decoded[i].String() != patch[i].String() {
+			t.Errorf("operation %d: got %s; expected %s", i, decoded[i], patch[i])
+		}
+	}
+}
+
+func TestSession_Update_raw(t *testing.T) {
+	var s Session
+	if _, err := s.Update(m.M("i", m.Raw("&#9733;"))); err != nil {
+		t.Fatal(err)
+	}
+	// The client parses raw HTML, so changing it must replace the node
+	// rather than set the data of the text node that it was parsed to.
+	patch, err := s.Update(m.M("i", m.Raw("&#9734;")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[[3,[0,0],{"raw":"\u0026#9734;"}]]`; string(data) != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestPatch_UnmarshalJSON_invalid(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`[[1]]`,
+		`[[1,[0]]]`,
+		`[[2,[0],"x"]]`,
+		`[[4,[0],"key"]]`,
+		`[[7,[],"x"]]`,
+		`[[9,[0]]]`,
+		`[[1,[0],["p"]]]`,
+		`[[1,[0],["p",["class"]]]]`,
+	}
+	for _, data := range tests {
+		var p Patch
+		if err := json.Unmarshal([]byte(data), &p); err == nil {
+			t.Errorf("Unmarshal(%s): expected error", data)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	const updates = 3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var session Session
+		stream := NewStream(w)
+		for n := 0; n < updates; n++ {
+			patch, err := session.Update(counter(n))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := stream.Send(patch); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	// The client keeps its tree when it reconnects, and the handler starts
+	// a new session.
+	var nodes []*m.Node
+	for connection := 0; connection < 2; connection++ {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("got Content-Type %q", ct)
+		}
+
+		var n int
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var patch Patch
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &patch); err != nil {
+				t.Fatal(err)
+			}
+			if nodes, err = diff.Apply(nodes, patch); err != nil {
+				t.Fatal(err)
+			}
+			if got, expected := renderNodes(nodes), m.RenderString(counter(n)); got != expected {
+				t.Errorf("connection %d, after update %d\ngot:\n%s\nexpected:\n%s", connection, n, got, expected)
+			}
+			n++
+		}
+		resp.Body.Close()
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n != updates {
+			t.Fatalf("got %d patches; expected %d", n, updates)
+		}
+	}
+}
+
+func renderNodes(nodes []*m.Node) string {
+	elements := make([]m.Element, len(nodes))
+	for i, n := range nodes {
+		elements[i] = n
+	}
+	return m.RenderString(m.S(elements...))
+}
+
+func TestScriptHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ScriptHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/live.js", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/javascript") {
+		t.Fatalf("got Content-Type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "function mLive(root, url)") {
+		t.Fatalf("unexpected script:\n%s", rec.Body.String())
+	}
+}
@@ -1,12 +1,19 @@
 package m
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
-	"html"
 	"io"
+	"iter"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 
+	"layeh.com/m/internal/css"
 	selectorpkg "layeh.com/m/internal/selector"
 )
 
@@ -20,12 +27,14 @@ type Element interface {
 }
 
 type internalElement interface {
-	renderHTML(w io.Writer) error
+	render(w *renderer) error
+	appendNodes(nodes []*Node) ([]*Node, error)
 }
 
 type htmlElement struct {
+	Namespace  string
 	TagName    string
-	Attributes []*attr
+	Attributes []Attribute
 	Children   []Element
 	Void       bool
 }
@@ -36,65 +45,196 @@ type htmlElement struct {
 //  tagname#id.class-1.class-2[attr-key-1=value][attr-key-2=value]
 //
 // The element ID (#), class names (.), and attributes ([]) are optional. Multiple
-// class names and attributes can be defined, but only one element ID.
-// If tagname is not defined, div is used.
+// class names and attributes can be defined. If multiple element IDs are
+// defined, the last one is used. If tagname is not defined, div is used.
+//
+// Attribute values can be quoted with ' or " (e.g. [title="Hello world"]), and
+// whitespace is allowed around attribute keys and values. A backslash escapes
+// any character, such as a quote in a quoted value or a . in a class name.
 //
 // The selector should be a constant value. If dynamic values are required for an ID,
 // class name, or attribute, omit the dynamic value from the selector string and use
 // Attr or Attrf instead.
 //
-// Multiple class attributes are merged together into a space-separated string.
+// Multiple class attributes are merged together into a space-separated string,
+// without duplicate class names. Classes and Class can be used for conditional
+// class names.
+//
+// Each attribute is rendered once, in the order described by
+// Renderer.SortAttributes. If the ID is given more than once, such as
+// in the selector and with Attr, the last one is used, as is the last value of
+// most other attributes. The values of token list attributes, such as rel and
+// aria-describedby, are joined without duplicates. Multiple style attributes,
+// such as those of Style, are merged together. A property that is declared
+// more than once keeps its first position and takes its last value, unless
+// an earlier value is !important.
 //
 // elements are the children of the HTML tag. If Attr and Attrf values are used,
 // they must be the first values included in elements.
 //
-// The function panics on an invalid selector.
+// The function panics on an invalid selector. NewElement can be used instead
+// for selectors that are not constant.
 func M(selector string, elements ...Element) Element {
-	sel, err := selectorpkg.Parse(selector)
+	e, err := newElement(HTMLNamespace, selector, elements)
 	if err != nil {
 		panic(err)
 	}
+	return e
+}
+
+// NewElement is like M, except that it returns a *SelectorError instead of
+// panicking if selector is invalid, such as when it comes from user
+// configuration.
+func NewElement(selector string, elements ...Element) (Element, error) {
+	e, err := newElement(HTMLNamespace, selector, elements)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Errors of invalid selectors, which are the Err of a SelectorError.
+var (
+	ErrInvalidSelector = selectorpkg.ErrInvalid
+	ErrInvalidID       = selectorpkg.ErrInvalidID
+	ErrInvalidClass    = selectorpkg.ErrInvalidClass
+	ErrInvalidAttr     = selectorpkg.ErrInvalidAttr
+)
+
+// SelectorError describes an invalid selector.
+type SelectorError struct {
+	// Err is one of ErrInvalidSelector, ErrInvalidID, ErrInvalidClass and
+	// ErrInvalidAttr.
+	Err      error
+	Selector string
+	// Offset is the byte offset of Selector where the error was found,
+	// such as the start of an invalid attribute.
+	Offset int
+}
+
+func (e *SelectorError) Error() string {
+	return "m: " + e.Err.Error() + " at offset " + strconv.Itoa(e.Offset) + " of " + strconv.Quote(e.Selector)
+}
 
-	tagName := "div"
-	if sel.TagName != "" {
-		tagName = strings.ToLower(sel.TagName)
+// Unwrap returns e.Err.
+func (e *SelectorError) Unwrap() error {
+	return e.Err
+}
+
+// selectorError converts an error of the selector package to a
+// *SelectorError.
+func selectorError(err error) error {
+	if err, ok := err.(*selectorpkg.Error); ok {
+		return &SelectorError{
+			Err:      err.Err,
+			Selector: err.Selector,
+			Offset:   err.Offset,
+		}
+	}
+	return err
+}
+
+// Namespaces of elements.
+const (
+	HTMLNamespace   = "http://www.w3.org/1999/xhtml"
+	SVGNamespace    = "http://www.w3.org/2000/svg"
+	MathMLNamespace = "http://www.w3.org/1998/Math/MathML"
+	xlinkNamespace  = "http://www.w3.org/1999/xlink"
+)
+
+// NS returns an element in the given namespace that is specified by selector.
+//
+// The selector syntax is the same as M, except that a tag name is required.
+// Unlike M, the case of the tag name is preserved, and there are no void
+// elements: elements without children are self-closed (e.g. <path/>).
+// namespace can be empty for XML elements that are not in a namespace.
+//
+// When rendered, an xmlns attribute is added to elements whose namespace
+// differs from their parent's, unless one is given explicitly. Elements that
+// use xlink: attributes have the xlink namespace declared if needed.
+//
+// The function panics on an invalid selector.
+func NS(namespace, selector string, elements ...Element) Element {
+	e, err := newElement(namespace, selector, elements)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// SVG returns an SVG element that is specified by selector. It is the same
+// as NS(SVGNamespace, selector, elements...).
+func SVG(selector string, elements ...Element) Element {
+	return NS(SVGNamespace, selector, elements...)
+}
+
+// MathML returns a MathML element that is specified by selector. It is the
+// same as NS(MathMLNamespace, selector, elements...).
+func MathML(selector string, elements ...Element) Element {
+	return NS(MathMLNamespace, selector, elements...)
+}
+
+func newElement(namespace, selector string, elements []Element) (*htmlElement, error) {
+	sel, err := selectorpkg.Parse(selector)
+	if err != nil {
+		return nil, selectorError(err)
+	}
+
+	tagName := sel.TagName
+	if namespace == HTMLNamespace {
+		if tagName == "" {
+			tagName = "div"
+		}
+		tagName = strings.ToLower(tagName)
+	} else if tagName == "" {
+		return nil, &SelectorError{
+			Err:      ErrInvalidSelector,
+			Selector: selector,
+		}
 	}
 
 	var id *string
 	if sel.ID != "" {
 		id = &sel.ID
 	}
-
 	classes := sel.Classes
-	for _, el := range elements {
-		if attribute, ok := el.(*attr); ok {
-			switch attribute.Key {
-			case "id":
-				id = &attribute.Value
-			case "class":
-				classes = append(classes, attribute.Value)
+
+	// Other attributes are rendered once, at the position of their first
+	// occurrence, with the values of all of their occurrences merged.
+	var others []Attribute
+	var values [][]string
+	positions := make(map[string]int)
+	addAttribute := func(attr Attribute) {
+		key := attr.Key
+		if namespace == HTMLNamespace {
+			key = strings.ToLower(key)
+		}
+		switch key {
+		case "id":
+			id = &attr.Value
+		case "class":
+			classes = append(classes, attr.Value)
+		default:
+			if i, ok := positions[key]; ok {
+				values[i] = append(values[i], attr.Value)
+				return
 			}
+			positions[key] = len(others)
+			others = append(others, attr)
+			values = append(values, []string{attr.Value})
 		}
 	}
-
-	attributes := make([]*attr, 0, 1+1+len(sel.Attributes))
-	if id != nil {
-		attributes = append(attributes, &attr{"id", *id})
-	}
-	if len(classes) > 0 {
-		attributes = append(attributes, &attr{"class", strings.Join(classes, " ")})
-	}
 	for _, attribute := range sel.Attributes {
-		attributes = append(attributes, &attr{attribute[0], attribute[1]})
+		addAttribute(Attribute{attribute[0], attribute[1]})
 	}
 
 	var children []Element
 	for i, el := range elements {
-		if attr, ok := el.(*attr); ok {
-			switch attr.Key {
-			case "id", "class":
-			default:
-				attributes = append(attributes, attr)
+		if attr, ok := el.(*Attribute); ok {
+			// A nil *Attribute is skipped, such as for a conditional
+			// attribute.
+			if attr != nil {
+				addAttribute(*attr)
 			}
 		} else if el != nil {
 			children = append([]Element(nil), elements[i:]...)
@@ -102,12 +242,114 @@ func M(selector string, elements ...Element) Element {
 		}
 	}
 
+	attributes := make([]Attribute, 0, 1+1+len(others))
+	if id != nil {
+		attributes = append(attributes, Attribute{"id", *id})
+	}
+	if class := joinTokens(classes); class != "" {
+		attributes = append(attributes, Attribute{"class", class})
+	}
+	for i, attr := range others {
+		if value, ok := mergeAttribute(strings.ToLower(attr.Key), values[i]); ok {
+			attributes = append(attributes, Attribute{attr.Key, value})
+		}
+	}
+
 	return &htmlElement{
+		Namespace:  namespace,
 		TagName:    tagName,
 		Attributes: attributes,
 		Children:   children,
-		Void:       voidElements[sel.TagName],
+		Void:       namespace == HTMLNamespace && voidElements[tagName],
+	}, nil
+}
+
+// tokenListAttributes are the attributes whose values are space-separated
+// lists of tokens, which are joined when the attribute is given more than
+// once.
+var tokenListAttributes = map[string]bool{
+	"accesskey":        true,
+	"aria-controls":    true,
+	"aria-describedby": true,
+	"aria-labelledby":  true,
+	"aria-owns":        true,
+	"headers":          true,
+	"ping":             true,
+	"rel":              true,
+	"sandbox":          true,
+}
+
+// mergeAttribute returns the value of the attribute key that is given with
+// values, and false if the attribute should be omitted.
+//
+// A single value is used as is. The values of token list attributes are
+// joined without duplicates, and style declarations are merged, and the
+// attribute is omitted if the result is empty. Otherwise, the last value is
+// used.
+func mergeAttribute(key string, values []string) (string, bool) {
+	if len(values) == 1 {
+		return values[0], true
+	}
+	var value string
+	switch {
+	case tokenListAttributes[key]:
+		value = joinTokens(values)
+	case key == "style":
+		value = mergeStyles(values)
+	default:
+		return values[len(values)-1], true
+	}
+	return value, value != ""
+}
+
+// joinTokens returns the space-separated tokens of lists, without
+// duplicates.
+func joinTokens(lists []string) string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, token := range strings.Fields(list) {
+			if !seen[token] {
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
 	}
+	return strings.Join(tokens, " ")
+}
+
+// mergeStyles returns the declarations of styles as a single style
+// attribute value. A property keeps the position of its first declaration,
+// and the value of its last one, unless an earlier one is !important.
+func mergeStyles(styles []string) string {
+	var properties []string
+	values := map[string]css.Declaration{}
+	for _, style := range styles {
+		for _, d := range css.ParseDeclarations(style) {
+			existing, ok := values[d.Property]
+			if !ok {
+				properties = append(properties, d.Property)
+			} else if existing.Important && !d.Important {
+				continue
+			}
+			values[d.Property] = d
+		}
+	}
+
+	var b strings.Builder
+	for _, property := range properties {
+		d := values[property]
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(d.Property)
+		b.WriteString(": ")
+		b.WriteString(d.Value)
+		if d.Important {
+			b.WriteString(" !important")
+		}
+	}
+	return b.String()
 }
 
 var voidElements = map[string]bool{
@@ -131,7 +373,7 @@ var voidElements = map[string]bool{
 
 func (*htmlElement) Element() Element { return nil }
 
-func (e *htmlElement) renderHTML(w io.Writer) error {
+func (e *htmlElement) render(w *renderer) error {
 	if _, err := io.WriteString(w, "<"); err != nil {
 		return err
 	}
@@ -139,25 +381,42 @@ func (e *htmlElement) renderHTML(w io.Writer) error {
 		return err
 	}
 
-	// Attributes
-	for _, attr := range e.Attributes {
-		if _, err := io.WriteString(w, " "); err != nil {
+	// Namespace declarations
+	namespace, xlink := w.namespace, w.xlink
+	if xmlns, ok := w.namespaceDeclaration(e); ok {
+		if err := writeAttribute(w, "xmlns", xmlns); err != nil {
 			return err
 		}
-		if _, err := io.WriteString(w, attr.Key); err != nil {
-			return err
-		}
-		if _, err := io.WriteString(w, "=\""); err != nil {
+	}
+	w.namespace = e.Namespace
+	if !w.xlink && usesXlink(e.Attributes) {
+		if err := writeAttribute(w, "xmlns:xlink", xlinkNamespace); err != nil {
 			return err
 		}
-		if _, err := io.WriteString(w, template.HTMLEscapeString(attr.Value)); err != nil {
+		w.xlink = true
+	}
+
+	// Attributes
+	attributes := e.Attributes
+	if w.SortAttributes {
+		attributes = append([]Attribute(nil), attributes...)
+		sort.Slice(attributes, func(i, j int) bool {
+			return attributes[i].Key < attributes[j].Key
+		})
+	}
+	for _, attr := range attributes {
+		if err := writeAttribute(w, attr.Key, attr.Value); err != nil {
 			return err
 		}
-		if _, err := io.WriteString(w, "\""); err != nil {
+	}
+
+	if (e.Void && w.XML) || (e.Namespace != HTMLNamespace && !hasChildren(e.Children)) {
+		if _, err := io.WriteString(w, "/>"); err != nil {
 			return err
 		}
+		w.namespace, w.xlink = namespace, xlink
+		return nil
 	}
-
 	if _, err := io.WriteString(w, ">"); err != nil {
 		return err
 	}
@@ -165,7 +424,7 @@ func (e *htmlElement) renderHTML(w io.Writer) error {
 	if !e.Void {
 		// Children
 		for _, el := range e.Children {
-			if err := Render(w, el); err != nil {
+			if err := w.render(el); err != nil {
 				return err
 			}
 		}
@@ -181,9 +440,65 @@ func (e *htmlElement) renderHTML(w io.Writer) error {
 		}
 	}
 
+	w.namespace, w.xlink = namespace, xlink
+	return nil
+}
+
+func writeAttribute(w *renderer, key, value string) error {
+	if _, err := io.WriteString(w, " "); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, key); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "=\""); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, w.escapeAttr(value)); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\""); err != nil {
+		return err
+	}
 	return nil
 }
 
+func hasChildren(children []Element) bool {
+	for _, el := range children {
+		if el != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func usesXlink(attributes []Attribute) bool {
+	for _, attr := range attributes {
+		if strings.HasPrefix(attr.Key, "xlink:") {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *htmlElement) appendNodes(nodes []*Node) ([]*Node, error) {
+	node := &Node{
+		Type:       ElementNode,
+		Namespace:  e.Namespace,
+		TagName:    e.TagName,
+		Attributes: append([]Attribute(nil), e.Attributes...),
+	}
+	if !e.Void {
+		for _, el := range e.Children {
+			var err error
+			if node.Children, err = appendNodes(node.Children, el); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return append(nodes, node), nil
+}
+
 // Document returns an element that renders the HTML5 doctype before elements.
 func Document(elements ...Element) Element {
 	newSlice := make([]Element, 1+len(elements))
@@ -213,25 +528,116 @@ type slice struct {
 
 func (e *slice) Element() Element { return nil }
 
-func (e *slice) renderHTML(w io.Writer) error {
+func (e *slice) render(w *renderer) error {
 	for _, element := range e.Elements {
-		if err := Render(w, element); err != nil {
+		if err := w.render(element); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (e *slice) appendNodes(nodes []*Node) ([]*Node, error) {
+	for _, element := range e.Elements {
+		var err error
+		if nodes, err = appendNodes(nodes, element); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
 // Attr returns an HTML element attribute with the given key and value.
 //
 // The return value is only valid when used as the first elements in calling M.
 func Attr(key, value string) Element {
-	return &attr{
+	return &Attribute{
 		Key:   key,
 		Value: value,
 	}
 }
 
+// Classes returns a class attribute of the class names in classes whose value
+// is true, or nil if there are none. The class names are sorted so that the
+// attribute is deterministic.
+//
+// As with Attr, the return value is only valid when used as the first elements
+// in calling M, where it is merged with the other class names:
+//
+//	M("button.btn", Classes(map[string]bool{
+//		"btn-primary": primary,
+//		"active":      active,
+//	}))
+func Classes(classes map[string]bool) Element {
+	var names []string
+	for name, ok := range classes {
+		if ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return Attr("class", strings.Join(names, " "))
+}
+
+// Class returns a class attribute of name if cond is true, or nil otherwise.
+//
+// As with Attr, the return value is only valid when used as the first elements
+// in calling M, where it is merged with the other class names:
+//
+//	M("li.nav-item", Class("active", active), Class("disabled", disabled))
+func Class(name string, cond bool) Element {
+	if !cond {
+		return nil
+	}
+	return Attr("class", name)
+}
+
+// Style returns a style attribute of the CSS declarations of properties, or
+// nil if there are none. The declarations are sorted by property name so that
+// the attribute is deterministic, which also places shorthand properties
+// (e.g. margin) before their longhands (e.g. margin-top).
+//
+// Invalid declarations are silently discarded, without an error: properties
+// with an empty value, invalid property names, and values that could end
+// their declaration or inject other CSS, such as those containing ;, {, },
+// comments, unclosed strings or parentheses, backslash escapes, expression()
+// or javascript: URLs. Values that must not be dropped should be validated
+// before calling Style.
+//
+// As with Attr, the return value is only valid when used as the first elements
+// in calling M, where it is merged with the other style attributes:
+//
+//	M("div.bar", Style(map[string]string{
+//		"width":      strconv.Itoa(percent) + "%",
+//		"background": color,
+//	}))
+func Style(properties map[string]string) Element {
+	var names []string
+	for name, value := range properties {
+		if css.ValidProperty(name) && css.ValidValue(value) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.TrimSpace(properties[name]))
+	}
+	return Attr("style", b.String())
+}
+
 // Attrf returns an HTML element attribute with the given key and value.
 // The value is formatted using fmt.
 //
@@ -240,17 +646,21 @@ func Attrf(key, valueFormat string, x ...interface{}) Element {
 	return Attr(key, fmt.Sprintf(valueFormat, x...))
 }
 
-type attr struct {
+// Attribute is an HTML element attribute.
+//
+// An *Attribute is an Element that is only valid when used as the first
+// elements in calling M.
+type Attribute struct {
 	Key, Value string
 }
 
-func (*attr) Element() Element  { return nil }
-func (*attr) renderHTML() error { return nil }
+// Element implements Element.
+func (*Attribute) Element() Element { return nil }
 
 // T returns an escaped text element.
 func T(text string) Element {
-	return &raw{
-		Raw: html.EscapeString(text),
+	return &textEl{
+		Text: text,
 	}
 }
 
@@ -266,20 +676,47 @@ func F(format string, x ...interface{}) Element {
 	return T(fmt.Sprintf(format, x...))
 }
 
+type textEl struct {
+	Text string
+}
+
+func (*textEl) Element() Element { return nil }
+
+func (e *textEl) render(w *renderer) error {
+	if _, err := io.WriteString(w, w.escapeText(e.Text)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *textEl) appendNodes(nodes []*Node) ([]*Node, error) {
+	return appendText(nodes, e.Text), nil
+}
+
 type raw struct {
 	Raw string
 }
 
 func (*raw) Element() Element { return nil }
 
-func (e *raw) renderHTML(w io.Writer) error {
+func (e *raw) render(w *renderer) error {
 	if _, err := io.WriteString(w, e.Raw); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (e *raw) appendNodes(nodes []*Node) ([]*Node, error) {
+	return append(nodes, &Node{
+		Type: RawNode,
+		Data: e.Raw,
+	}), nil
+}
+
 // If returns ifTrue if cond is true, nil otherwise.
+//
+// ifTrue is constructed even if cond is false. IfFunc can be used instead
+// for elements that are expensive to construct.
 func If(cond bool, ifTrue Element) Element {
 	return IfElse(cond, ifTrue, nil)
 }
@@ -292,7 +729,230 @@ func IfElse(cond bool, ifTrue, ifFalse Element) Element {
 	return ifFalse
 }
 
+// IfFunc returns the element returned by ifTrue if cond is true, nil
+// otherwise. Unlike If, ifTrue is only called if cond is true.
+func IfFunc(cond bool, ifTrue func() Element) Element {
+	if cond {
+		return ifTrue()
+	}
+	return nil
+}
+
+// IfElseFunc returns the element returned by ifTrue or ifFalse, depending on
+// the value of cond. Unlike IfElse, only the function that is chosen is called.
+func IfElseFunc(cond bool, ifTrue, ifFalse func() Element) Element {
+	if cond {
+		return ifTrue()
+	}
+	return ifFalse()
+}
+
+// Branch is a branch of Switch or Cond, which is created with Case, When or
+// Default.
+type Branch struct {
+	match func(value any) bool
+	fn    func() Element
+}
+
+// Case returns a branch that is taken if the value of Switch equals value.
+// The values are compared as interface values, so they must have the same
+// type, and Switch panics if they are not comparable (e.g. slices).
+func Case(value any, fn func() Element) Branch {
+	return Branch{
+		match: func(v any) bool { return v == value },
+		fn:    fn,
+	}
+}
+
+// When returns a branch that is taken if cond is true.
+func When(cond bool, fn func() Element) Branch {
+	return Branch{
+		match: func(any) bool { return cond },
+		fn:    fn,
+	}
+}
+
+// Default returns a branch that is always taken. It should be the last
+// branch.
+func Default(fn func() Element) Branch {
+	return When(true, fn)
+}
+
+// Switch returns the element of the first branch that is taken for value,
+// or nil if none are taken. Only the function of that branch is called.
+//
+//	Switch(user.Role,
+//		Case("admin", func() Element { return adminPanel(user) }),
+//		Case("editor", func() Element { return editorPanel(user) }),
+//		Default(func() Element { return T("No access") }),
+//	)
+func Switch(value any, branches ...Branch) Element {
+	for _, b := range branches {
+		if b.match(value) {
+			return b.fn()
+		}
+	}
+	return nil
+}
+
+// Cond returns the element of the first branch that is taken, or nil if
+// none are taken, like a chain of if and else if statements. Only the
+// function of that branch is called. The branches are created with When and
+// Default.
+//
+//	Cond(
+//		When(err != nil, func() Element { return errorMessage(err) }),
+//		When(len(items) == 0, func() Element { return T("No items") }),
+//		Default(func() Element { return itemList(items) }),
+//	)
+func Cond(branches ...Branch) Element {
+	return Switch(nil, branches...)
+}
+
+// Lazy returns an element that calls fn each time it is rendered, and
+// renders the element that fn returns. fn is not called if the element is
+// discarded, such as by If.
+//
+// As attributes must be known when M is called, fn cannot return attributes.
+func Lazy(fn func() Element) Element {
+	return LazyErr(func() (Element, error) {
+		return fn(), nil
+	})
+}
+
+// LazyErr is like Lazy, except that fn can fail. If fn returns a non-nil
+// error, rendering stops and the error is returned by Render and Nodes, so
+// that components can report failures such as a failed lookup. The output
+// that was written before the error is not undone.
+//
+// RenderString ignores the error, so Render should be used for elements that
+// can fail.
+func LazyErr(fn func() (Element, error)) Element {
+	return &lazyEl{
+		Func: fn,
+	}
+}
+
+type lazyEl struct {
+	Func func() (Element, error)
+}
+
+func (*lazyEl) Element() Element { return nil }
+
+func (e *lazyEl) render(w *renderer) error {
+	el, err := e.Func()
+	if err != nil {
+		return err
+	}
+	return w.render(el)
+}
+
+func (e *lazyEl) appendNodes(nodes []*Node) ([]*Node, error) {
+	el, err := e.Func()
+	if err != nil {
+		return nil, err
+	}
+	return appendNodes(nodes, el)
+}
+
+// Boundary returns an error boundary, which is an element that renders child,
+// unless rendering it fails or panics, in which case the element that fallback
+// returns for the error is rendered instead. A panic is recovered as a
+// *PanicError.
+//
+// child is rendered into a buffer, so that a failure does not leave partial
+// output of it. fallback can be nil to render nothing on failure.
+//
+//	Boundary(weatherWidget(city), func(err error) Element {
+//		log.Printf("weather widget: %v", err)
+//		return M("p.error", T("Weather is unavailable."))
+//	})
+func Boundary(child Element, fallback func(err error) Element) Element {
+	return &boundary{
+		Child:    child,
+		Fallback: fallback,
+	}
+}
+
+// PanicError is the error of a panic that is recovered by Boundary.
+type PanicError struct {
+	// Value is the value that was passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panic, as returned by debug.Stack.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("m: panic: %v", e.Value)
+}
+
+// Unwrap returns e.Value if it is an error, or nil otherwise.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type boundary struct {
+	Child    Element
+	Fallback func(err error) Element
+}
+
+func (*boundary) Element() Element { return nil }
+
+func (e *boundary) render(w *renderer) error {
+	var b bytes.Buffer
+	child := *w
+	child.Writer = &b
+	if err := recoverPanic(func() error { return child.render(e.Child) }); err != nil {
+		return w.render(e.fallback(err))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (e *boundary) appendNodes(nodes []*Node) ([]*Node, error) {
+	var children []*Node
+	err := recoverPanic(func() error {
+		var err error
+		children, err = appendNodes(nil, e.Child)
+		return err
+	})
+	if err != nil {
+		return appendNodes(nodes, e.fallback(err))
+	}
+	for _, n := range children {
+		if n.Type == TextNode {
+			nodes = appendText(nodes, n.Data)
+		} else {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+func (e *boundary) fallback(err error) Element {
+	if e.Fallback == nil {
+		return nil
+	}
+	return e.Fallback(err)
+}
+
+// recoverPanic calls fn, and returns a *PanicError if it panics.
+func recoverPanic(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{
+				Value: v,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return fn()
+}
+
 // Range returns an element that is called for every index from 0 to n.
+//
+// To loop over the items of a slice, Each can be used instead.
 func Range(n int, fn func(i int) Element) Element {
 	return For(0, n, 1, fn)
 }
@@ -314,16 +974,16 @@ type forLoop struct {
 
 func (*forLoop) Element() Element { return nil }
 
-func (e *forLoop) renderHTML(w io.Writer) error {
+func (e *forLoop) render(w *renderer) error {
 	if e.Step >= 0 {
 		for i := e.Start; i < e.End; i += e.Step {
-			if err := Render(w, e.Func(i)); err != nil {
+			if err := w.render(e.Func(i)); err != nil {
 				return err
 			}
 		}
 	} else {
 		for i := e.Start; i >= e.End; i += e.Step {
-			if err := Render(w, e.Func(i)); err != nil {
+			if err := w.render(e.Func(i)); err != nil {
 				return err
 			}
 		}
@@ -331,6 +991,24 @@ func (e *forLoop) renderHTML(w io.Writer) error {
 	return nil
 }
 
+func (e *forLoop) appendNodes(nodes []*Node) ([]*Node, error) {
+	var err error
+	if e.Step >= 0 {
+		for i := e.Start; i < e.End; i += e.Step {
+			if nodes, err = appendNodes(nodes, e.Func(i)); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for i := e.Start; i >= e.End; i += e.Step {
+			if nodes, err = appendNodes(nodes, e.Func(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nodes, nil
+}
+
 // Group returns an element that renders contiguous values that match the group function.
 //
 // From 0 to N (i), the group function is evaluated
@@ -354,7 +1032,7 @@ type groupEl struct {
 
 func (*groupEl) Element() Element { return nil }
 
-func (e *groupEl) renderHTML(w io.Writer) error {
+func (e *groupEl) render(w *renderer) error {
 	if e.N == 0 {
 		return nil
 	}
@@ -362,11 +1040,147 @@ func (e *groupEl) renderHTML(w io.Writer) error {
 	lower := 0
 	for i := 1; i < e.N; i++ {
 		if !e.Group(lower, i) {
-			if err := Render(w, e.Render(lower, i)); err != nil {
+			if err := w.render(e.Render(lower, i)); err != nil {
 				return err
 			}
 			lower = i
 		}
 	}
-	return Render(w, e.Render(lower, e.N))
+	return w.render(e.Render(lower, e.N))
+}
+
+func (e *groupEl) appendNodes(nodes []*Node) ([]*Node, error) {
+	if e.N == 0 {
+		return nodes, nil
+	}
+
+	var err error
+	lower := 0
+	for i := 1; i < e.N; i++ {
+		if !e.Group(lower, i) {
+			if nodes, err = appendNodes(nodes, e.Render(lower, i)); err != nil {
+				return nil, err
+			}
+			lower = i
+		}
+	}
+	return appendNodes(nodes, e.Render(lower, e.N))
+}
+
+// Each returns an element that is called for every item of items, with its
+// index.
+//
+// As with For, fn is called when the element is rendered, so items that are
+// changed in the meantime are rendered as they are at that time.
+func Each[T any](items []T, fn func(item T, i int) Element) Element {
+	return &seqEl{
+		Seq: func(yield func(Element) bool) {
+			for i, item := range items {
+				if !yield(fn(item, i)) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// Map returns an element that is called for every key and value of m, in the
+// order of the sorted keys.
+func Map[K cmp.Ordered, V any](m map[K]V, fn func(key K, value V) Element) Element {
+	return MapFunc(m, cmp.Compare[K], fn)
+}
+
+// MapFunc returns an element that is called for every key and value of m, in
+// the order of the keys sorted by compare, which returns a negative number
+// when a < b, a positive number when a > b, and zero when a == b.
+func MapFunc[K comparable, V any](m map[K]V, compare func(a, b K) int, fn func(key K, value V) Element) Element {
+	return &seqEl{
+		Seq: func(yield func(Element) bool) {
+			for _, key := range slices.SortedFunc(maps.Keys(m), compare) {
+				if !yield(fn(key, m[key])) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// Keyed returns an element that is called for every item of items, with its
+// index, in the order of the sorted keys of the items. Items with equal keys
+// keep their order, and items is not modified.
+//
+// As with Each, fn is called when the element is rendered.
+func Keyed[T any, K cmp.Ordered](items []T, key func(item T) K, fn func(item T, i int) Element) Element {
+	return &seqEl{
+		Seq: func(yield func(Element) bool) {
+			indexes := make([]int, len(items))
+			for i := range indexes {
+				indexes[i] = i
+			}
+			slices.SortStableFunc(indexes, func(a, b int) int {
+				return cmp.Compare(key(items[a]), key(items[b]))
+			})
+			for _, i := range indexes {
+				if !yield(fn(items[i], i)) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// Seq returns an element that is called for every value of seq.
+//
+// seq is iterated each time the element is rendered.
+func Seq[T any](seq iter.Seq[T], fn func(value T) Element) Element {
+	return &seqEl{
+		Seq: func(yield func(Element) bool) {
+			for v := range seq {
+				if !yield(fn(v)) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// Seq2 returns an element that is called for every pair of values of seq,
+// such as the keys and values of a sequence returned by maps.All.
+//
+// seq is iterated each time the element is rendered.
+func Seq2[K, V any](seq iter.Seq2[K, V], fn func(key K, value V) Element) Element {
+	return &seqEl{
+		Seq: func(yield func(Element) bool) {
+			for k, v := range seq {
+				if !yield(fn(k, v)) {
+					return
+				}
+			}
+		},
+	}
+}
+
+type seqEl struct {
+	Seq iter.Seq[Element]
+}
+
+func (*seqEl) Element() Element { return nil }
+
+func (e *seqEl) render(w *renderer) error {
+	for el := range e.Seq {
+		if err := w.render(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *seqEl) appendNodes(nodes []*Node) ([]*Node, error) {
+	var err error
+	for el := range e.Seq {
+		if nodes, err = appendNodes(nodes, el); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
 }
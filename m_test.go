@@ -1,6 +1,12 @@
 package m
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -95,6 +101,76 @@ func Test_map(t *testing.T) {
 			M("p.text-right", If(false, Attr("class", "m-0")), Attr("class", "alpha-25"), T("Text")),
 			`<p class="text-right alpha-25">Text</p>`,
 		},
+		{
+			M("button.btn.btn-sm", Classes(map[string]bool{"btn-sm": true, "btn-primary": true, "active": true, "disabled": false}), Attr("class", " btn  mt-2 ")),
+			`<button class="btn btn-sm active btn-primary mt-2"></button>`,
+		},
+		{
+			M("li", Class("active", true), Class("disabled", false), Classes(nil), Class("active", true)),
+			`<li class="active"></li>`,
+		},
+		{
+			M("li", Class("active", false), Attr("class", "")),
+			`<li></li>`,
+		},
+		{
+			M("button", (*Attribute)(nil), Attr("type", "submit"), (*Attribute)(nil), T("a")),
+			`<button type="submit">a</button>`,
+		},
+		{
+			M("div", Style(map[string]string{"margin-top": "1em", "color": " red ", "margin": "0 auto", "display": ""})),
+			`<div style="color: red; margin: 0 auto; margin-top: 1em"></div>`,
+		},
+		{
+			M("div", Style(map[string]string{
+				"color":       "red; background: url(x)",
+				"width":       "expression(alert(1))",
+				"font-family": `"a\"b"`,
+				"a:b":         "c",
+				"--x":         `"</div>"`,
+			})),
+			`<div style="--x: &#34;&lt;/div&gt;&#34;"></div>`,
+		},
+		{
+			M("div", Style(nil), Style(map[string]string{"color": "red;"})),
+			`<div></div>`,
+		},
+		{
+			M("p[title=a][style='color: red; margin: 0']", Attr("data-x", "b"), Style(map[string]string{"padding": "1px", "color": "blue"}), Attr("style", "margin: 2px !important; color: green")),
+			`<p title="a" style="color: green; margin: 2px !important; padding: 1px" data-x="b"></p>`,
+		},
+		{
+			M("p", Attr("style", "font-weight: bold !important"), Style(map[string]string{"font-weight": "normal"})),
+			`<p style="font-weight: bold !important"></p>`,
+		},
+		{
+			M("p", Attr("style", "color:red"), T("a")),
+			`<p style="color:red">a</p>`,
+		},
+		{
+			M("p", Attr("style", ""), Attr("style", " ; ")),
+			`<p></p>`,
+		},
+		{
+			M("a#a[href=/a][title=x][id=b]", Attr("title", "y"), Attr("href", "/b"), Attr("ID", "c"), Attr("Title", "z")),
+			`<a id="c" href="/b" title="z"></a>`,
+		},
+		{
+			M("a[rel=noopener]", Attr("rel", "nofollow  noopener"), Attr("aria-describedby", "a"), Attr("data-x", "1"), Attr("aria-describedby", "b a"), Attr("rel", "external")),
+			`<a rel="noopener nofollow external" aria-describedby="a b" data-x="1"></a>`,
+		},
+		{
+			M("a", Attr("rel", " "), Attr("rel", ""), Attr("title", "a"), Attr("title", "")),
+			`<a title=""></a>`,
+		},
+		{
+			M("a", Attr("rel", ""), T("a"), Attr("rel", "b")),
+			`<a rel="">a</a>`,
+		},
+		{
+			SVG("svg[viewBox='0 0 1 1']", Attr("viewbox", "0 0 2 2"), Attr("viewBox", "0 0 3 3")),
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 3 3" viewbox="0 0 2 2"/>`,
+		},
 		{
 			M("p#id-0", If(false, Attr("id", "id-1")), T("Text")),
 			`<p id="id-0">Text</p>`,
@@ -115,3 +191,511 @@ func Test_map(t *testing.T) {
 		}
 	}
 }
+
+func TestNewElement(t *testing.T) {
+	if el, err := NewElement("a#b.c[href=/]", T("d")); err != nil {
+		t.Errorf("NewElement: %s", err)
+	} else if output := RenderString(el); output != `<a id="b" class="c" href="/">d</a>` {
+		t.Errorf("NewElement: got %q", output)
+	}
+
+	tests := []struct {
+		Selector string
+		Err      error
+		Offset   int
+	}{
+		{"div#", ErrInvalidID, 3},
+		{"div#a.b.", ErrInvalidClass, 7},
+		{"a[href=/]b", ErrInvalidSelector, 9},
+		{"a[href=/][title", ErrInvalidAttr, 9},
+		{"a[href='/]", ErrInvalidAttr, 1},
+	}
+
+	for _, tt := range tests {
+		el, err := NewElement(tt.Selector)
+		var selectorErr *SelectorError
+		if el != nil || !errors.As(err, &selectorErr) {
+			t.Errorf("NewElement(%q): got (%v, %v)", tt.Selector, el, err)
+			continue
+		}
+		if !errors.Is(err, tt.Err) || selectorErr.Selector != tt.Selector || selectorErr.Offset != tt.Offset {
+			t.Errorf("NewElement(%q): got %#v; expected %v at offset %d", tt.Selector, selectorErr, tt.Err, tt.Offset)
+		}
+	}
+
+	_, err := Find(M("div"), "ul > li a[")
+	if selectorErr, ok := err.(*SelectorError); !ok || selectorErr.Err != ErrInvalidAttr || selectorErr.Offset != 9 {
+		t.Errorf("Find: got %#v", err)
+	}
+	if expected := `m: invalid attribute at offset 9 of "ul > li a["`; err.Error() != expected {
+		t.Errorf("Find: got error %q; expected %q", err, expected)
+	}
+}
+
+func Test_find(t *testing.T) {
+	el := M("div#app",
+		M("ul.nav",
+			M("li", M("a[href=/]", T("Home"))),
+			M("li.active", M("a[href=/about]", T("About"))),
+		),
+		M("p.lead", T("Hello, "), F("%s", "World")),
+		M("form",
+			M("input[type=checkbox][name=remember]"),
+		),
+	)
+
+	tests := []struct {
+		Selector string
+		Expected []string
+	}{
+		{"ul > li.active", []string{"About"}},
+		{"#app a", []string{"Home", "About"}},
+		{"div > a", nil},
+		{"div > ul li > a", []string{"Home", "About"}},
+		{"a[href^='/a']", []string{"About"}},
+		{"p.lead, li.active", []string{"About", "Hello, World"}},
+		{"P[class~=lead]", []string{"Hello, World"}},
+		{"input[type=checkbox]", []string{""}},
+		{"*[name]", []string{""}},
+	}
+
+	for _, tt := range tests {
+		nodes, err := FindAll(el, tt.Selector)
+		if err != nil {
+			t.Errorf("FindAll(%q): %s", tt.Selector, err)
+			continue
+		}
+		var texts []string
+		for _, n := range nodes {
+			texts = append(texts, n.Text())
+		}
+		if !reflect.DeepEqual(texts, tt.Expected) {
+			t.Errorf("FindAll(%q)\ngot:\n%#v\nexpected:\n%#v", tt.Selector, texts, tt.Expected)
+		}
+	}
+
+	if n, err := Find(el, "li a"); err != nil || n == nil {
+		t.Errorf("Find: got (%v, %v)", n, err)
+	} else if href, _ := n.Attr("href"); href != "/" {
+		t.Errorf("Find: got href %q; expected %q", href, "/")
+	}
+	if n, err := Find(el, "table"); n != nil || err != nil {
+		t.Errorf("Find: got (%v, %v); expected (nil, nil)", n, err)
+	}
+	if _, err := Find(el, "a["); err == nil {
+		t.Errorf("Find: expected error for invalid selector")
+	}
+}
+
+func TestRenderer_xml(t *testing.T) {
+	tests := []struct {
+		Renderer Renderer
+		Element  Element
+		Expected string
+	}{
+		{
+			Renderer{XML: true},
+			M("p", T("A"), M("br"), M("img[src=a.png][alt=A & B]")),
+			`<p xmlns="http://www.w3.org/1999/xhtml">A<br/><img src="a.png" alt="A &amp; B"/></p>`,
+		},
+		{
+			Renderer{XML: true},
+			M("div", M("span")),
+			`<div xmlns="http://www.w3.org/1999/xhtml"><span></span></div>`,
+		},
+		{
+			Renderer{XML: true},
+			M("p", Attr("title", "a\tb\nc\x00"), T("x\x01y\n")),
+			`<p xmlns="http://www.w3.org/1999/xhtml" title="a&#x9;b&#xA;c">xy` + "\n" + `</p>`,
+		},
+		{
+			Renderer{XML: true, XMLDeclaration: true},
+			M("html"),
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+		},
+		{
+			Renderer{XMLDeclaration: true},
+			M("p", M("br"), T("x\x01y")),
+			"<p><br>x\x01y</p>",
+		},
+		{
+			Renderer{XML: true},
+			Document(M("html", M("body", SVG("svg", SVG("path"))), M("p"))),
+			"<!DOCTYPE html>\n" + `<html xmlns="http://www.w3.org/1999/xhtml"><body><svg xmlns="http://www.w3.org/2000/svg"><path/></svg></body><p></p></html>`,
+		},
+		{
+			Renderer{XML: true},
+			NS("", "package", NS("", "metadata"), S(M("p"), M("p"))),
+			`<package><metadata/><p xmlns="http://www.w3.org/1999/xhtml"></p><p xmlns="http://www.w3.org/1999/xhtml"></p></package>`,
+		},
+		{
+			Renderer{},
+			M("html", M("body")),
+			`<html><body></body></html>`,
+		},
+		{
+			Renderer{SortAttributes: true},
+			M("a#a.b[title=c][href=d]", Attr("data-e", "f"), Attr("aria-label", "g"), M("img[src=h][alt=i]")),
+			`<a aria-label="g" class="b" data-e="f" href="d" id="a" title="c"><img alt="i" src="h"></a>`,
+		},
+		{
+			Renderer{SortAttributes: true, XML: true},
+			SVG("svg[width=1][height=1]", SVG("use[xlink:href=#a][fill=red]")),
+			`<svg xmlns="http://www.w3.org/2000/svg" height="1" width="1"><use xmlns:xlink="http://www.w3.org/1999/xlink" fill="red" xlink:href="#a"/></svg>`,
+		},
+	}
+
+	for _, tt := range tests {
+		if output := tt.Renderer.RenderString(tt.Element); output != tt.Expected {
+			t.Errorf("%+v.RenderString(%#v)\ngot:\n%#v\nexpected:\n%#v", tt.Renderer, tt.Element, output, tt.Expected)
+		}
+	}
+}
+
+func Test_namespaces(t *testing.T) {
+	const svgNS = `xmlns="http://www.w3.org/2000/svg"`
+	chart := M("figure",
+		SVG("svg[viewBox=0 0 10 10]",
+			SVG("defs", SVG("linearGradient#g", SVG("stop[offset=0]"))),
+			SVG("path[d=M0 0L10 10]"),
+			SVG("use[xlink:href=#g]"),
+			SVG("foreignObject", M("p", T("Label"), M("br"))),
+		),
+	)
+
+	tests := []struct {
+		Renderer Renderer
+		Element  Element
+		Expected string
+	}{
+		{
+			Renderer{},
+			chart,
+			`<figure><svg ` + svgNS + ` viewBox="0 0 10 10"><defs><linearGradient id="g"><stop offset="0"/></linearGradient></defs>` +
+				`<path d="M0 0L10 10"/><use xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="#g"/>` +
+				`<foreignObject><p>Label<br></p></foreignObject></svg></figure>`,
+		},
+		{
+			Renderer{XML: true},
+			SVG("foreignObject", M("p", M("br"))),
+			`<foreignObject ` + svgNS + `><p xmlns="http://www.w3.org/1999/xhtml"><br/></p></foreignObject>`,
+		},
+		{
+			Renderer{},
+			SVG("svg[xmlns=http://www.w3.org/2000/svg]", SVG("circle[r=1]"), If(false, T(""))),
+			`<svg ` + svgNS + `><circle r="1"/></svg>`,
+		},
+		{
+			Renderer{},
+			S(MathML("math", MathML("mi", T("x"))), SVG("g", nil)),
+			`<math xmlns="http://www.w3.org/1998/Math/MathML"><mi>x</mi></math><g ` + svgNS + `/>`,
+		},
+		{
+			Renderer{XML: true},
+			NS("", "rss[version=2.0]", NS("", "channel", NS("", "link", T("https://example.com/")), NS("", "pubDate"))),
+			`<rss version="2.0"><channel><link>https://example.com/</link><pubDate/></channel></rss>`,
+		},
+		{
+			Renderer{XML: true},
+			NS("http://www.w3.org/2005/Atom", "feed", NS("", "x", NS("", "y"))),
+			`<feed xmlns="http://www.w3.org/2005/Atom"><x xmlns=""><y/></x></feed>`,
+		},
+	}
+
+	for _, tt := range tests {
+		if output := tt.Renderer.RenderString(tt.Element); output != tt.Expected {
+			t.Errorf("%+v.RenderString(%#v)\ngot:\n%#v\nexpected:\n%#v", tt.Renderer, tt.Element, output, tt.Expected)
+		}
+		nodes, err := Nodes(tt.Element)
+		if err != nil {
+			t.Fatal(err)
+		}
+		elements := make([]Element, len(nodes))
+		for i, n := range nodes {
+			elements[i] = n
+		}
+		if output := tt.Renderer.RenderString(S(elements...)); output != tt.Expected {
+			t.Errorf("%+v.RenderString(Nodes(%#v))\ngot:\n%#v\nexpected:\n%#v", tt.Renderer, tt.Element, output, tt.Expected)
+		}
+	}
+
+	if n, err := Find(chart, "svg linearGradient#g"); err != nil || n == nil || n.Namespace != SVGNamespace {
+		t.Errorf("Find: got (%v, %v)", n, err)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	el := Document(M("html",
+		M("head", M("title", T("Welcome")), M("style", Raw("p { color: red; }"))),
+		M("body",
+			M("h1", T("Welcome,  Alice")),
+			M("p", T("Thanks for\n signing up. "), M("a[href=https://example.com/confirm]", T("Confirm")), T(" your email.")),
+			M("ul",
+				M("li", T("One")),
+				M("li", T("Two"), M("ol[start=3]", M("li", T("Three")), M("li", T("Four")))),
+			),
+			M("blockquote", M("p", T("Quote 1")), M("p", T("Quote 2"))),
+			M("table",
+				M("tr", M("th", T("Item")), M("th", T("Price"))),
+				M("tr", M("td", T("Widget")), M("td", T("$1.00"))),
+				M("tr", M("td", T("Gadget deluxe")), M("td", T("$10.00"))),
+			),
+			M("pre", T("a  b\n  c")),
+			M("p", Raw("Tom &amp; <b>Jerry</b>"), M("br"), M("img[alt=Logo]")),
+			M("hr"),
+			M("p", M("a[href=https://example.com/]", T("https://example.com/")), T(" "), M("a[href=#top]", T("Top"))),
+		),
+	))
+	expected := `Welcome, Alice
+
+Thanks for signing up. Confirm (https://example.com/confirm) your email.
+
+- One
+- Two
+  3. Three
+  4. Four
+
+> Quote 1
+>
+> Quote 2
+
+Item           Price
+-------------  ------
+Widget         $1.00
+Gadget deluxe  $10.00
+
+a  b
+  c
+
+Tom & Jerry
+Logo
+
+----
+
+https://example.com/ Top
+`
+	var b strings.Builder
+	if err := RenderText(&b, el); err != nil {
+		t.Fatal(err)
+	}
+	if output := b.String(); output != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestEach(t *testing.T) {
+	items := []string{"a"}
+	calls := 0
+	el := M("ul", Each(items, func(item string, i int) Element {
+		calls++
+		return M("li", F("%d%s", i, item))
+	}))
+	if calls != 0 {
+		t.Fatalf("fn was called %d times before rendering", calls)
+	}
+	items[0] = "b"
+	if output, expected := RenderString(el), `<ul><li>0b</li></ul>`; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+
+	ids := map[int]string{3: "c", 1: "a", 2: "b"}
+	el = S(
+		MapFunc(ids, func(a, b int) int { return b - a }, func(id int, name string) Element {
+			return F("%d%s", id, name)
+		}),
+		Seq2(maps.All(map[string]int{"x": 1}), func(k string, v int) Element {
+			return F("%s%d", k, v)
+		}),
+		Seq(slices.Values([]Element{T("y"), nil, T("z")}), func(el Element) Element {
+			return el
+		}),
+		Keyed([]string{"bb", "a", "cc", "d"}, func(s string) int { return len(s) }, func(s string, i int) Element {
+			return F("%d%s", i, s)
+		}),
+	)
+	nodes, err := Nodes(el)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(n.Data)
+	}
+	if output, expected := b.String(), "3c2b1ax1yz1a3d0bb2cc"; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	var called []string
+	branch := func(name string) func() Element {
+		return func() Element {
+			called = append(called, name)
+			return T(name)
+		}
+	}
+	tests := []struct {
+		Element  func() Element
+		Expected string
+		Called   []string
+	}{
+		{func() Element { return IfFunc(true, branch("a")) }, "a", []string{"a"}},
+		{func() Element { return IfFunc(false, branch("a")) }, "", nil},
+		{func() Element { return IfElseFunc(false, branch("a"), branch("b")) }, "b", []string{"b"}},
+		{func() Element {
+			return Switch("b", Case("a", branch("a")), Case("b", branch("b")), Default(branch("c")))
+		}, "b", []string{"b"}},
+		{func() Element {
+			return Switch(3, Case("3", branch("a")), Case(int64(3), branch("b")), Default(branch("c")))
+		}, "c", []string{"c"}},
+		{func() Element { return Switch(1, Case(2, branch("a"))) }, "", nil},
+		{func() Element {
+			return Switch(1, When(false, branch("a")), When(true, branch("b")), Case(1, branch("c")))
+		}, "b", []string{"b"}},
+		{func() Element {
+			return Cond(When(false, branch("a")), When(true, branch("b")), When(true, branch("c")))
+		}, "b", []string{"b"}},
+		{func() Element { return Cond(When(false, branch("a")), Default(branch("b"))) }, "b", []string{"b"}},
+		{func() Element { return Cond() }, "", nil},
+		{
+			func() Element {
+				return M("p", IfFunc(true, func() Element { return Attr("title", "a") }), T("b"))
+			},
+			`<p title="a">b</p>`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		called = nil
+		if output := RenderString(tt.Element()); output != tt.Expected {
+			t.Errorf("got %#v; expected %#v", output, tt.Expected)
+		}
+		if !reflect.DeepEqual(called, tt.Called) {
+			t.Errorf("%#v: called %v; expected %v", tt.Expected, called, tt.Called)
+		}
+	}
+}
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	count := Lazy(func() Element {
+		calls++
+		return F("%d", calls)
+	})
+	el := M("p", count, T(" "), If(false, count), count)
+	if calls != 0 {
+		t.Fatalf("fn was called %d times before rendering", calls)
+	}
+	for _, expected := range []string{`<p>1 2</p>`, `<p>3 4</p>`} {
+		if output := RenderString(el); output != expected {
+			t.Errorf("got %#v; expected %#v", output, expected)
+		}
+	}
+
+	errNotFound := errors.New("not found")
+	el = M("div",
+		LazyErr(func() (Element, error) { return M("p", T("a")), nil }),
+		LazyErr(func() (Element, error) { return nil, errNotFound }),
+		Lazy(func() Element {
+			t.Error("rendering did not stop at the error")
+			return nil
+		}),
+	)
+	var b strings.Builder
+	if err := Render(&b, el); !errors.Is(err, errNotFound) {
+		t.Errorf("Render returned error %v; expected %v", err, errNotFound)
+	}
+	if output, expected := b.String(), `<div><p>a</p>`; output != expected {
+		t.Errorf("got %#v; expected %#v", output, expected)
+	}
+	if _, err := Nodes(el); !errors.Is(err, errNotFound) {
+		t.Errorf("Nodes returned error %v; expected %v", err, errNotFound)
+	}
+	if err := RenderText(&b, el); !errors.Is(err, errNotFound) {
+		t.Errorf("RenderText returned error %v; expected %v", err, errNotFound)
+	}
+}
+
+func TestBoundary(t *testing.T) {
+	errFailed := errors.New("failed")
+	fallback := func(err error) Element {
+		return M("p.error", T(err.Error()))
+	}
+	tests := []struct {
+		Element  Element
+		Expected string
+		Error    error
+	}{
+		{
+			M("div", T("a"), Boundary(M("p", T("b")), fallback), T("c")),
+			`<div>a<p>b</p>c</div>`,
+			nil,
+		},
+		{
+			M("div", Boundary(M("p", T("a"), LazyErr(func() (Element, error) { return nil, errFailed })), fallback)),
+			`<div><p class="error">failed</p></div>`,
+			nil,
+		},
+		{
+			M("div", Boundary(M("p", T("a"), Lazy(func() Element { panic(errFailed) })), fallback)),
+			`<div><p class="error">m: panic: failed</p></div>`,
+			nil,
+		},
+		{
+			M("div", Boundary(Lazy(func() Element { panic("a") }), nil), T("b")),
+			`<div>b</div>`,
+			nil,
+		},
+		{
+			Boundary(
+				Boundary(LazyErr(func() (Element, error) { return nil, errFailed }), func(err error) Element {
+					return LazyErr(func() (Element, error) { return nil, fmt.Errorf("fallback: %w", err) })
+				}),
+				fallback,
+			),
+			`<p class="error">fallback: failed</p>`,
+			nil,
+		},
+		{
+			M("div", Boundary(LazyErr(func() (Element, error) { return nil, errors.New("a") }), func(err error) Element {
+				return LazyErr(func() (Element, error) { return nil, errFailed })
+			})),
+			`<div>`,
+			errFailed,
+		},
+		{
+			SVG("svg", Boundary(SVG("path"), fallback)),
+			`<svg xmlns="http://www.w3.org/2000/svg"><path/></svg>`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := Render(&b, tt.Element); !errors.Is(err, tt.Error) {
+			t.Errorf("got error %v; expected %v", err, tt.Error)
+		}
+		if output := b.String(); output != tt.Expected {
+			t.Errorf("got %#v; expected %#v", output, tt.Expected)
+		}
+		if tt.Error != nil {
+			continue
+		}
+		nodes, err := Nodes(tt.Element)
+		if err != nil {
+			t.Errorf("Nodes returned error %v", err)
+		}
+		b.Reset()
+		for _, n := range nodes {
+			Render(&b, n)
+		}
+		if output := b.String(); output != tt.Expected {
+			t.Errorf("Nodes rendered as %#v; expected %#v", output, tt.Expected)
+		}
+	}
+
+	var panicErr *PanicError
+	err := recoverPanic(func() error { panic(errFailed) })
+	if !errors.As(err, &panicErr) || !errors.Is(err, errFailed) || len(panicErr.Stack) == 0 {
+		t.Errorf("got %#v; expected a *PanicError of %v", err, errFailed)
+	}
+}
@@ -0,0 +1,942 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const codeIndent = 4
+
+// block is a block of a document that is being parsed.
+type block struct {
+	Kind     Kind
+	Parent   *block
+	Children []*block
+	Open     bool
+
+	// Content is the text of the lines that were added to the block.
+	Content string
+
+	// StartLine and EndLine are the first and last lines of the block, which
+	// are used to detect blank lines between the items of lists.
+	StartLine, EndLine int
+
+	// Level is set for Heading.
+	Level int
+	// List is set for List and Item.
+	List listData
+	// Fenced, FenceChar, FenceLength, FenceOffset and Info are set for
+	// CodeBlock.
+	Fenced      bool
+	FenceChar   byte
+	FenceLength int
+	FenceOffset int
+	Info        string
+	// HTMLType is the kind of start condition of an HTMLBlock, from 1 to 7.
+	HTMLType int
+	// Aligns and Rows are set for Table.
+	Aligns []Align
+	Rows   [][]string
+}
+
+type listData struct {
+	Ordered      bool
+	BulletChar   byte
+	Start        int
+	Delimiter    byte
+	Tight        bool
+	Padding      int
+	MarkerOffset int
+}
+
+func (b *block) lastChild() *block {
+	if len(b.Children) == 0 {
+		return nil
+	}
+	return b.Children[len(b.Children)-1]
+}
+
+func (b *block) remove() {
+	children := b.Parent.Children
+	for i, child := range children {
+		if child == b {
+			b.Parent.Children = append(children[:i:i], children[i+1:]...)
+			return
+		}
+	}
+}
+
+func canContain(parent, child Kind) bool {
+	switch parent {
+	case Document, BlockQuote, Item:
+		return child != Item
+	case List:
+		return child == Item
+	}
+	return false
+}
+
+func acceptsLines(kind Kind) bool {
+	return kind == Paragraph || kind == CodeBlock || kind == HTMLBlock || kind == Table
+}
+
+// parser is a CommonMark block parser. Each line is matched against the open
+// blocks, and then against the starts of new blocks. The remainder of the
+// line is added to the innermost block.
+type parser struct {
+	Doc         *block
+	Tip         *block
+	OldTip      *block
+	LastMatched *block
+	AllClosed   bool
+
+	Line       string
+	LineNumber int
+
+	// Offset is the byte offset into Line, and Column is the column taking
+	// tab stops into account. PartiallyConsumedTab is whether the tab at
+	// Offset was only partially consumed.
+	Offset               int
+	Column               int
+	PartiallyConsumedTab bool
+
+	NextNonspace       int
+	NextNonspaceColumn int
+	Indent             int
+	Indented           bool
+	Blank              bool
+
+	Refs map[string]reference
+}
+
+type reference struct {
+	Destination string
+	Title       string
+}
+
+var lineEndings = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func (p *parser) parse(source string) *Node {
+	p.Doc = &block{
+		Kind:      Document,
+		Open:      true,
+		StartLine: 1,
+	}
+	p.Tip = p.Doc
+	p.Refs = map[string]reference{}
+
+	source = lineEndings.Replace(source)
+	source = strings.TrimSuffix(source, "\n")
+	var lines []string
+	if source != "" {
+		lines = strings.Split(source, "\n")
+	}
+	for _, line := range lines {
+		p.incorporateLine(line)
+	}
+	for p.Tip != nil {
+		p.finalize(p.Tip, len(lines))
+	}
+	return p.node(p.Doc)
+}
+
+func (p *parser) incorporateLine(line string) {
+	container := p.Doc
+	p.OldTip = p.Tip
+	p.Offset = 0
+	p.Column = 0
+	p.Blank = false
+	p.PartiallyConsumedTab = false
+	p.LineNumber++
+	p.Line = strings.ReplaceAll(line, "\x00", "\uFFFD")
+
+	// Match the line against the open blocks.
+	allMatched := true
+	for {
+		last := container.lastChild()
+		if last == nil || !last.Open {
+			break
+		}
+		container = last
+		p.findNextNonspace()
+		switch p.continueBlock(container) {
+		case 1:
+			allMatched = false
+		case 2:
+			// The line closed a fenced code block.
+			return
+		}
+		if !allMatched {
+			container = container.Parent
+			break
+		}
+	}
+
+	p.AllClosed = container == p.OldTip
+	p.LastMatched = container
+
+	// Try to start new blocks, unless the last matched block is a leaf.
+	matchedLeaf := container.Kind != Paragraph && container.Kind != Table && acceptsLines(container.Kind)
+	for !matchedLeaf {
+		p.findNextNonspace()
+		started := 0
+		for _, start := range blockStarts {
+			if started = start(p, container); started != 0 {
+				break
+			}
+		}
+		if started == 0 {
+			p.advanceNextNonspace()
+			break
+		}
+		container = p.Tip
+		if started == 2 {
+			matchedLeaf = true
+		}
+	}
+
+	// Add the rest of the line to the appropriate block.
+	if !p.AllClosed && !p.Blank && p.Tip.Kind == Paragraph {
+		// Lazy paragraph continuation.
+		p.addLine()
+		return
+	}
+	p.closeUnmatchedBlocks()
+	switch {
+	case container.Kind == Table:
+		p.addRow()
+	case acceptsLines(container.Kind):
+		p.addLine()
+		if container.Kind == HTMLBlock && container.HTMLType <= 5 && htmlBlockClose[container.HTMLType].MatchString(p.Line[p.Offset:]) {
+			p.finalize(container, p.LineNumber)
+		}
+	case p.Offset < len(p.Line) && !p.Blank:
+		p.addChild(Paragraph)
+		p.advanceNextNonspace()
+		p.addLine()
+	}
+}
+
+// continueBlock matches the line against the open block b. It returns 0 if
+// the line continues b, 1 if it does not, and 2 if the line was consumed.
+func (p *parser) continueBlock(b *block) int {
+	switch b.Kind {
+	case Document, List:
+		return 0
+	case BlockQuote:
+		if p.Indented || peek(p.Line, p.NextNonspace) != '>' {
+			return 1
+		}
+		p.advanceNextNonspace()
+		p.advanceOffset(1, false)
+		if isSpaceOrTab(peek(p.Line, p.Offset)) {
+			p.advanceOffset(1, true)
+		}
+		return 0
+	case Item:
+		switch {
+		case p.Blank:
+			if len(b.Children) == 0 {
+				// An item can begin with at most one blank line.
+				return 1
+			}
+			p.advanceNextNonspace()
+		case p.Indent >= b.List.MarkerOffset+b.List.Padding:
+			p.advanceOffset(b.List.MarkerOffset+b.List.Padding, true)
+		default:
+			return 1
+		}
+		return 0
+	case CodeBlock:
+		if b.Fenced {
+			if p.Indent <= 3 && peek(p.Line, p.NextNonspace) == b.FenceChar {
+				if n := closingFence(p.Line[p.NextNonspace:]); n >= b.FenceLength {
+					p.finalize(b, p.LineNumber)
+					return 2
+				}
+			}
+			for i := b.FenceOffset; i > 0 && isSpaceOrTab(peek(p.Line, p.Offset)); i-- {
+				p.advanceOffset(1, true)
+			}
+			return 0
+		}
+		switch {
+		case p.Indent >= codeIndent:
+			p.advanceOffset(codeIndent, true)
+		case p.Blank:
+			p.advanceNextNonspace()
+		default:
+			return 1
+		}
+		return 0
+	case HTMLBlock:
+		if p.Blank && (b.HTMLType == 6 || b.HTMLType == 7) {
+			return 1
+		}
+		return 0
+	case Paragraph, Table:
+		if p.Blank {
+			return 1
+		}
+		return 0
+	}
+	return 1
+}
+
+// blockStarts are the functions that start new blocks, in order of
+// precedence. They return 0 if no block was started, 1 if a container block
+// was started, and 2 if a leaf block was started.
+var blockStarts = []func(p *parser, container *block) int{
+	(*parser).startBlockQuote,
+	(*parser).startATXHeading,
+	(*parser).startFencedCode,
+	(*parser).startHTMLBlock,
+	(*parser).startTable,
+	(*parser).startSetextHeading,
+	(*parser).startThematicBreak,
+	(*parser).startItem,
+	(*parser).startIndentedCode,
+}
+
+func (p *parser) startBlockQuote(container *block) int {
+	if p.Indented || peek(p.Line, p.NextNonspace) != '>' {
+		return 0
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(1, false)
+	if isSpaceOrTab(peek(p.Line, p.Offset)) {
+		p.advanceOffset(1, true)
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(BlockQuote)
+	return 1
+}
+
+var (
+	atxClosingOnly = regexp.MustCompile(`^[ \t]*#+[ \t]*$`)
+	atxClosing     = regexp.MustCompile(`[ \t]+#+[ \t]*$`)
+)
+
+func (p *parser) startATXHeading(container *block) int {
+	if p.Indented {
+		return 0
+	}
+	rest := p.Line[p.NextNonspace:]
+	level := 0
+	for level < len(rest) && rest[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(rest) && !isSpaceOrTab(rest[level])) {
+		return 0
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(level, false)
+	p.closeUnmatchedBlocks()
+	heading := p.addChild(Heading)
+	heading.Level = level
+	content := p.Line[p.Offset:]
+	content = atxClosingOnly.ReplaceAllString(content, "")
+	content = atxClosing.ReplaceAllString(content, "")
+	heading.Content = content
+	p.advanceOffset(len(p.Line)-p.Offset, false)
+	return 2
+}
+
+func (p *parser) startFencedCode(container *block) int {
+	if p.Indented {
+		return 0
+	}
+	rest := p.Line[p.NextNonspace:]
+	c := peek(rest, 0)
+	if c != '`' && c != '~' {
+		return 0
+	}
+	n := 0
+	for n < len(rest) && rest[n] == c {
+		n++
+	}
+	if n < 3 || (c == '`' && strings.IndexByte(rest[n:], '`') != -1) {
+		return 0
+	}
+	p.closeUnmatchedBlocks()
+	code := p.addChild(CodeBlock)
+	code.Fenced = true
+	code.FenceChar = c
+	code.FenceLength = n
+	code.FenceOffset = p.Indent
+	p.advanceNextNonspace()
+	p.advanceOffset(n, false)
+	return 2
+}
+
+// closingFence returns the length of the closing code fence s, or 0 if s is
+// not a closing code fence.
+func closingFence(s string) int {
+	c := peek(s, 0)
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	if n < 3 || strings.Trim(s[n:], " \t") != "" {
+		return 0
+	}
+	return n
+}
+
+const (
+	tagName             = `[A-Za-z][A-Za-z0-9-]*`
+	attributeName       = `[a-zA-Z_:][a-zA-Z0-9:._-]*`
+	attributeValue      = `(?:[^"'=<>` + "`" + `\x00-\x20]+|'[^']*'|"[^"]*")`
+	attribute           = `(?:\s+` + attributeName + `(?:\s*=\s*` + attributeValue + `)?)`
+	openTag             = `<` + tagName + attribute + `*\s*/?>`
+	closeTag            = `</` + tagName + `\s*[>]`
+	htmlComment         = `<!-->|<!--->|<!--[\s\S]*?-->`
+	processingInstr     = `[<][?][\s\S]*?[?][>]`
+	declaration         = `<![A-Za-z]+[^>]*>`
+	cdata               = `<!\[CDATA\[[\s\S]*?\]\]>`
+	htmlTag             = `(?:` + openTag + `|` + closeTag + `|` + htmlComment + `|` + processingInstr + `|` + declaration + `|` + cdata + `)`
+	htmlBlockSixPattern = `address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[123456]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul`
+)
+
+var htmlBlockOpen = [...]*regexp.Regexp{
+	1: regexp.MustCompile(`(?i)^<(?:script|pre|textarea|style)(?:\s|>|$)`),
+	2: regexp.MustCompile(`^<!--`),
+	3: regexp.MustCompile(`^<[?]`),
+	4: regexp.MustCompile(`^<![A-Za-z]`),
+	5: regexp.MustCompile(`^<!\[CDATA\[`),
+	6: regexp.MustCompile(`(?i)^<[/]?(?:` + htmlBlockSixPattern + `)(?:\s|[/]?[>]|$)`),
+	7: regexp.MustCompile(`(?i)^(?:` + openTag + `|` + closeTag + `)\s*$`),
+}
+
+var htmlBlockClose = [...]*regexp.Regexp{
+	1: regexp.MustCompile(`(?i)</(?:script|pre|textarea|style)>`),
+	2: regexp.MustCompile(`-->`),
+	3: regexp.MustCompile(`\?>`),
+	4: regexp.MustCompile(`>`),
+	5: regexp.MustCompile(`\]\]>`),
+}
+
+func (p *parser) startHTMLBlock(container *block) int {
+	if p.Indented || peek(p.Line, p.NextNonspace) != '<' {
+		return 0
+	}
+	rest := p.Line[p.NextNonspace:]
+	for htmlType := 1; htmlType <= 7; htmlType++ {
+		if !htmlBlockOpen[htmlType].MatchString(rest) {
+			continue
+		}
+		// Type 7 cannot interrupt a paragraph.
+		lazy := !p.AllClosed && !p.Blank && p.Tip.Kind == Paragraph
+		if htmlType == 7 && (container.Kind == Paragraph || lazy) {
+			return 0
+		}
+		p.closeUnmatchedBlocks()
+		// The indentation is part of the HTML block, so the offset is not
+		// advanced.
+		b := p.addChild(HTMLBlock)
+		b.HTMLType = htmlType
+		return 2
+	}
+	return 0
+}
+
+// startTable starts a table if the line is a delimiter row, and the last
+// line of the paragraph is a header row with the same number of cells.
+func (p *parser) startTable(container *block) int {
+	if p.Indented || container.Kind != Paragraph {
+		return 0
+	}
+	rest := p.Line[p.NextNonspace:]
+	if strings.IndexByte(rest, '|') == -1 {
+		return 0
+	}
+	aligns, ok := parseDelimiterRow(rest)
+	if !ok {
+		return 0
+	}
+	lines := strings.Split(strings.TrimSuffix(container.Content, "\n"), "\n")
+	header := splitRow(lines[len(lines)-1])
+	if len(header) != len(aligns) {
+		return 0
+	}
+
+	p.closeUnmatchedBlocks()
+	startLine := p.LineNumber - 1
+	if len(lines) > 1 {
+		container.Content = strings.Join(lines[:len(lines)-1], "\n") + "\n"
+		p.finalize(container, p.LineNumber-2)
+	} else {
+		container.remove()
+		p.Tip = container.Parent
+	}
+	table := p.addChild(Table)
+	table.StartLine = startLine
+	table.Aligns = aligns
+	table.Rows = [][]string{header}
+	p.advanceOffset(len(p.Line)-p.Offset, false)
+	return 2
+}
+
+func parseDelimiterRow(s string) ([]Align, bool) {
+	cells := splitRow(s)
+	aligns := make([]Align, len(cells))
+	for i, cell := range cells {
+		left := strings.HasPrefix(cell, ":")
+		right := strings.HasSuffix(cell, ":")
+		dashes := strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+		switch {
+		case left && right:
+			aligns[i] = AlignCenter
+		case left:
+			aligns[i] = AlignLeft
+		case right:
+			aligns[i] = AlignRight
+		}
+	}
+	return aligns, true
+}
+
+// splitRow splits a table row into its trimmed cells. Escaped pipes are
+// unescaped.
+func splitRow(s string) []string {
+	s = strings.Trim(s, " \t")
+	s = strings.TrimPrefix(s, "|")
+	if strings.HasSuffix(s, "|") && !strings.HasSuffix(s, `\|`) {
+		s = s[:len(s)-1]
+	}
+	var cells []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, s[start:i])
+			start = i + 1
+		}
+	}
+	cells = append(cells, s[start:])
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(strings.Trim(cell, " \t"), `\|`, "|")
+	}
+	return cells
+}
+
+func (p *parser) addRow() {
+	rest := p.Line[p.Offset:]
+	if strings.Trim(rest, " \t") == "" {
+		return
+	}
+	table := p.Tip
+	row := splitRow(rest)
+	if len(row) > len(table.Aligns) {
+		row = row[:len(table.Aligns)]
+	}
+	for len(row) < len(table.Aligns) {
+		row = append(row, "")
+	}
+	table.Rows = append(table.Rows, row)
+}
+
+func (p *parser) startSetextHeading(container *block) int {
+	if p.Indented || container.Kind != Paragraph {
+		return 0
+	}
+	rest := strings.TrimRight(p.Line[p.NextNonspace:], " \t")
+	c := peek(rest, 0)
+	if (c != '=' && c != '-') || strings.Trim(rest, string(c)) != "" {
+		return 0
+	}
+	p.closeUnmatchedBlocks()
+	for peek(container.Content, 0) == '[' {
+		n := p.parseReference(container.Content)
+		if n == 0 {
+			break
+		}
+		container.Content = container.Content[n:]
+	}
+	if container.Content == "" {
+		return 0
+	}
+	heading := &block{
+		Kind:      Heading,
+		Parent:    container.Parent,
+		Open:      true,
+		Content:   container.Content,
+		StartLine: container.StartLine,
+		Level:     2,
+	}
+	if c == '=' {
+		heading.Level = 1
+	}
+	siblings := container.Parent.Children
+	siblings[len(siblings)-1] = heading
+	p.Tip = heading
+	p.advanceOffset(len(p.Line)-p.Offset, false)
+	return 2
+}
+
+// isThematicBreak reports whether s consists of 3 or more matching *, - or _
+// characters, and spaces or tabs.
+func isThematicBreak(s string) bool {
+	c := peek(s, 0)
+	if c != '*' && c != '-' && c != '_' {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case c:
+			n++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+func (p *parser) startThematicBreak(container *block) int {
+	if p.Indented || !isThematicBreak(p.Line[p.NextNonspace:]) {
+		return 0
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(ThematicBreak)
+	p.advanceOffset(len(p.Line)-p.Offset, false)
+	return 2
+}
+
+func (p *parser) startItem(container *block) int {
+	if p.Indented && container.Kind != List {
+		return 0
+	}
+	data, ok := p.parseListMarker(container)
+	if !ok {
+		return 0
+	}
+	p.closeUnmatchedBlocks()
+	if p.Tip.Kind != List || !listsMatch(p.Tip.List, data) {
+		list := p.addChild(List)
+		list.List = data
+	}
+	item := p.addChild(Item)
+	item.List = data
+	return 1
+}
+
+func (p *parser) parseListMarker(container *block) (listData, bool) {
+	data := listData{
+		Tight:        true,
+		MarkerOffset: p.Indent,
+	}
+	if p.Indent >= codeIndent {
+		return data, false
+	}
+	rest := p.Line[p.NextNonspace:]
+	var markerLength int
+	switch c := peek(rest, 0); {
+	case c == '*' || c == '+' || c == '-':
+		data.BulletChar = c
+		markerLength = 1
+	case isDigit(c):
+		n := 0
+		for n < len(rest) && n < 10 && isDigit(rest[n]) {
+			n++
+		}
+		if n > 9 || (peek(rest, n) != '.' && peek(rest, n) != ')') {
+			return data, false
+		}
+		data.Ordered = true
+		data.Start, _ = strconv.Atoi(rest[:n])
+		data.Delimiter = rest[n]
+		markerLength = n + 1
+		// Only lists that start with 1 can interrupt a paragraph.
+		if container.Kind == Paragraph && data.Start != 1 {
+			return data, false
+		}
+	default:
+		return data, false
+	}
+
+	// The marker must be followed by whitespace.
+	if c := peek(rest, markerLength); c != 0 && !isSpaceOrTab(c) {
+		return data, false
+	}
+	// An empty item cannot interrupt a paragraph.
+	if container.Kind == Paragraph && strings.Trim(rest[markerLength:], " \t") == "" {
+		return data, false
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(markerLength, true)
+	spacesStartColumn := p.Column
+	spacesStartOffset := p.Offset
+	for {
+		p.advanceOffset(1, true)
+		if p.Column-spacesStartColumn >= 5 || !isSpaceOrTab(peek(p.Line, p.Offset)) {
+			break
+		}
+	}
+	blankItem := p.Offset >= len(p.Line)
+	spacesAfterMarker := p.Column - spacesStartColumn
+	if spacesAfterMarker >= 5 || spacesAfterMarker < 1 || blankItem {
+		// The content starts one space after the marker, and the rest of
+		// the spaces are part of the content (e.g. an indented code block).
+		data.Padding = markerLength + 1
+		p.Column = spacesStartColumn
+		p.Offset = spacesStartOffset
+		if isSpaceOrTab(peek(p.Line, p.Offset)) {
+			p.advanceOffset(1, true)
+		}
+	} else {
+		data.Padding = markerLength + spacesAfterMarker
+	}
+	return data, true
+}
+
+func listsMatch(a, b listData) bool {
+	return a.Ordered == b.Ordered && a.Delimiter == b.Delimiter && a.BulletChar == b.BulletChar
+}
+
+func (p *parser) startIndentedCode(container *block) int {
+	if !p.Indented || p.Tip.Kind == Paragraph || p.Blank {
+		return 0
+	}
+	p.advanceOffset(codeIndent, true)
+	p.closeUnmatchedBlocks()
+	p.addChild(CodeBlock)
+	return 2
+}
+
+func (p *parser) findNextNonspace() {
+	i := p.Offset
+	column := p.Column
+	for i < len(p.Line) {
+		if p.Line[i] == ' ' {
+			column++
+		} else if p.Line[i] == '\t' {
+			column += 4 - column%4
+		} else {
+			break
+		}
+		i++
+	}
+	p.Blank = i == len(p.Line)
+	p.NextNonspace = i
+	p.NextNonspaceColumn = column
+	p.Indent = column - p.Column
+	p.Indented = p.Indent >= codeIndent
+}
+
+func (p *parser) advanceNextNonspace() {
+	p.Offset = p.NextNonspace
+	p.Column = p.NextNonspaceColumn
+	p.PartiallyConsumedTab = false
+}
+
+// advanceOffset advances count bytes, or count columns if columns is true,
+// in which case a tab can be partially consumed.
+func (p *parser) advanceOffset(count int, columns bool) {
+	for count > 0 && p.Offset < len(p.Line) {
+		if p.Line[p.Offset] != '\t' {
+			p.PartiallyConsumedTab = false
+			p.Offset++
+			p.Column++
+			count--
+			continue
+		}
+		charsToTab := 4 - p.Column%4
+		if columns {
+			p.PartiallyConsumedTab = charsToTab > count
+			if charsToTab > count {
+				charsToTab = count
+			} else {
+				p.Offset++
+			}
+			p.Column += charsToTab
+			count -= charsToTab
+		} else {
+			p.PartiallyConsumedTab = false
+			p.Column += charsToTab
+			p.Offset++
+			count--
+		}
+	}
+}
+
+func (p *parser) addLine() {
+	if p.PartiallyConsumedTab {
+		// Replace the rest of the tab with spaces.
+		p.Offset++
+		p.Tip.Content += strings.Repeat(" ", 4-p.Column%4)
+	}
+	p.Tip.Content += p.Line[p.Offset:] + "\n"
+}
+
+func (p *parser) addChild(kind Kind) *block {
+	for !canContain(p.Tip.Kind, kind) {
+		p.finalize(p.Tip, p.LineNumber-1)
+	}
+	b := &block{
+		Kind:      kind,
+		Parent:    p.Tip,
+		Open:      true,
+		StartLine: p.LineNumber,
+	}
+	p.Tip.Children = append(p.Tip.Children, b)
+	p.Tip = b
+	return b
+}
+
+func (p *parser) closeUnmatchedBlocks() {
+	if p.AllClosed {
+		return
+	}
+	for p.OldTip != p.LastMatched {
+		parent := p.OldTip.Parent
+		p.finalize(p.OldTip, p.LineNumber-1)
+		p.OldTip = parent
+	}
+	p.AllClosed = true
+}
+
+var blankLine = regexp.MustCompile(`^[ \t]*$`)
+
+// finalize closes b, whose last line is line.
+func (p *parser) finalize(b *block, line int) {
+	b.Open = false
+	b.EndLine = line
+	p.Tip = b.Parent
+
+	switch b.Kind {
+	case Paragraph:
+		hasReferences := false
+		for peek(b.Content, 0) == '[' {
+			n := p.parseReference(b.Content)
+			if n == 0 {
+				break
+			}
+			b.Content = b.Content[n:]
+			hasReferences = true
+		}
+		if hasReferences && strings.TrimSpace(b.Content) == "" {
+			b.remove()
+		}
+	case CodeBlock:
+		if b.Fenced {
+			// The first line is the info string.
+			info := b.Content
+			b.Content = ""
+			if i := strings.IndexByte(info, '\n'); i != -1 {
+				info, b.Content = info[:i], info[i+1:]
+			}
+			b.Info = unescapeString(strings.TrimSpace(info))
+			break
+		}
+		lines := strings.Split(b.Content, "\n")
+		for len(lines) > 0 && blankLine.MatchString(lines[len(lines)-1]) {
+			lines = lines[:len(lines)-1]
+		}
+		b.Content = strings.Join(lines, "\n") + "\n"
+		b.EndLine = b.StartLine + len(lines) - 1
+	case HTMLBlock:
+		b.Content = strings.TrimSuffix(b.Content, "\n")
+	case Item:
+		if last := b.lastChild(); last != nil {
+			b.EndLine = last.EndLine
+		} else {
+			b.EndLine = b.StartLine
+		}
+	case List:
+		b.List.Tight = true
+		for i, item := range b.Children {
+			if i < len(b.Children)-1 && endsWithBlankLine(item, b.Children[i+1]) {
+				b.List.Tight = false
+			}
+			for j, child := range item.Children {
+				if j < len(item.Children)-1 && endsWithBlankLine(child, item.Children[j+1]) {
+					b.List.Tight = false
+				}
+			}
+		}
+		b.EndLine = b.lastChild().EndLine
+	}
+}
+
+// endsWithBlankLine reports whether there is a blank line between b and
+// next.
+func endsWithBlankLine(b, next *block) bool {
+	return b.EndLine != next.StartLine-1
+}
+
+// node converts b to a Node, parsing the inline content of its leaves.
+func (p *parser) node(b *block) *Node {
+	n := &Node{
+		Kind: b.Kind,
+	}
+	switch b.Kind {
+	case List:
+		n.Ordered = b.List.Ordered
+		n.Start = b.List.Start
+		n.Tight = b.List.Tight
+	case CodeBlock, HTMLBlock:
+		n.Literal = b.Content
+		n.Info = b.Info
+	case Paragraph, Heading:
+		n.Level = b.Level
+		n.Children = p.parseInlines(strings.TrimSpace(b.Content))
+	case Table:
+		head := &Node{
+			Kind: TableHead,
+		}
+		body := &Node{
+			Kind: TableBody,
+		}
+		for i, row := range b.Rows {
+			r := &Node{
+				Kind: TableRow,
+			}
+			for j, cell := range row {
+				r.Children = append(r.Children, &Node{
+					Kind:     TableCell,
+					Children: p.parseInlines(cell),
+					Header:   i == 0,
+					Align:    b.Aligns[j],
+				})
+			}
+			if i == 0 {
+				head.Children = append(head.Children, r)
+			} else {
+				body.Children = append(body.Children, r)
+			}
+		}
+		n.Children = append(n.Children, head)
+		if len(body.Children) > 0 {
+			n.Children = append(n.Children, body)
+		}
+	}
+	for _, child := range b.Children {
+		n.Children = append(n.Children, p.node(child))
+	}
+	return n
+}
+
+// peek returns the byte of s at i, or 0 if i is out of range.
+func peek(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func isSpaceOrTab(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,862 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// inline is a node of inline content that is being parsed. Inlines are
+// linked so that they can be moved into emphasis and links once their
+// closing delimiters are found.
+type inline struct {
+	Kind        Kind
+	Literal     string
+	Destination string
+	Title       string
+
+	Parent, FirstChild, LastChild, Prev, Next *inline
+}
+
+func text(s string) *inline {
+	return &inline{
+		Kind:    Text,
+		Literal: s,
+	}
+}
+
+func (n *inline) appendChild(child *inline) {
+	child.unlink()
+	child.Parent = n
+	if n.LastChild != nil {
+		n.LastChild.Next = child
+		child.Prev = n.LastChild
+	} else {
+		n.FirstChild = child
+	}
+	n.LastChild = child
+}
+
+func (n *inline) insertAfter(sibling *inline) {
+	sibling.unlink()
+	sibling.Parent = n.Parent
+	sibling.Prev = n
+	sibling.Next = n.Next
+	if n.Next != nil {
+		n.Next.Prev = sibling
+	} else {
+		n.Parent.LastChild = sibling
+	}
+	n.Next = sibling
+}
+
+func (n *inline) unlink() {
+	if n.Prev != nil {
+		n.Prev.Next = n.Next
+	} else if n.Parent != nil {
+		n.Parent.FirstChild = n.Next
+	}
+	if n.Next != nil {
+		n.Next.Prev = n.Prev
+	} else if n.Parent != nil {
+		n.Parent.LastChild = n.Prev
+	}
+	n.Parent, n.Prev, n.Next = nil, nil, nil
+}
+
+// nodes converts the children of n to Nodes, merging adjacent text.
+func (n *inline) nodes() []*Node {
+	var nodes []*Node
+	for child := n.FirstChild; child != nil; child = child.Next {
+		if child.Kind == Text {
+			if child.Literal == "" {
+				continue
+			}
+			if last := len(nodes) - 1; last >= 0 && nodes[last].Kind == Text {
+				nodes[last].Literal += child.Literal
+				continue
+			}
+		}
+		nodes = append(nodes, &Node{
+			Kind:        child.Kind,
+			Children:    child.nodes(),
+			Literal:     child.Literal,
+			Destination: child.Destination,
+			Title:       child.Title,
+		})
+	}
+	return nodes
+}
+
+// delimiter is a run of * or _ characters that may open or close emphasis.
+type delimiter struct {
+	Char          byte
+	Count         int
+	OriginalCount int
+	Node          *inline
+	Prev, Next    *delimiter
+
+	CanOpen, CanClose bool
+}
+
+// bracket is a [ or ![ that may open a link or image.
+type bracket struct {
+	Node          *inline
+	Prev          *bracket
+	PrevDelimiter *delimiter
+	// Index is the position of the [ in the subject.
+	Index int
+	Image bool
+	// Active is false if the bracket cannot open a link, because links
+	// cannot contain other links. BracketAfter is whether another bracket
+	// follows it.
+	Active       bool
+	BracketAfter bool
+}
+
+// inlineParser parses the inline content of a block.
+type inlineParser struct {
+	Subject    string
+	Pos        int
+	Delimiters *delimiter
+	Brackets   *bracket
+	Refs       map[string]reference
+}
+
+func (p *parser) parseInlines(s string) []*Node {
+	ip := inlineParser{
+		Subject: s,
+		Refs:    p.Refs,
+	}
+	var root inline
+	for ip.parseInline(&root) {
+	}
+	ip.processEmphasis(nil)
+	return root.nodes()
+}
+
+// parseReference parses a link reference definition at the start of s,
+// and returns its length, or 0 if there is none.
+func (p *parser) parseReference(s string) int {
+	ip := inlineParser{
+		Subject: s,
+	}
+	return ip.parseReference(p.Refs)
+}
+
+func (p *inlineParser) peek() byte {
+	return peek(p.Subject, p.Pos)
+}
+
+// match advances past the match of re, which must be anchored, and returns
+// the matched text.
+func (p *inlineParser) match(re *regexp.Regexp) (string, bool) {
+	loc := re.FindStringIndex(p.Subject[p.Pos:])
+	if loc == nil {
+		return "", false
+	}
+	s := p.Subject[p.Pos : p.Pos+loc[1]]
+	p.Pos += loc[1]
+	return s, true
+}
+
+func (p *inlineParser) parseInline(block *inline) bool {
+	if p.Pos >= len(p.Subject) {
+		return false
+	}
+	var ok bool
+	switch c := p.peek(); c {
+	case '\n':
+		ok = p.parseNewline(block)
+	case '\\':
+		ok = p.parseBackslash(block)
+	case '`':
+		ok = p.parseBackticks(block)
+	case '*', '_':
+		ok = p.parseDelimiter(c, block)
+	case '[':
+		ok = p.parseOpenBracket(block)
+	case '!':
+		ok = p.parseBang(block)
+	case ']':
+		ok = p.parseCloseBracket(block)
+	case '<':
+		ok = p.parseAutolink(block) || p.parseHTMLTag(block)
+	case '&':
+		ok = p.parseEntity(block)
+	default:
+		ok = p.parseString(block)
+	}
+	if !ok {
+		_, size := utf8.DecodeRuneInString(p.Subject[p.Pos:])
+		block.appendChild(text(p.Subject[p.Pos : p.Pos+size]))
+		p.Pos += size
+	}
+	return true
+}
+
+const specialChars = "\n`[]\\!<&*_"
+
+func (p *inlineParser) parseString(block *inline) bool {
+	end := p.Pos
+	for end < len(p.Subject) && strings.IndexByte(specialChars, p.Subject[end]) == -1 {
+		end++
+	}
+	if end == p.Pos {
+		return false
+	}
+	block.appendChild(text(p.Subject[p.Pos:end]))
+	p.Pos = end
+	return true
+}
+
+func (p *inlineParser) parseNewline(block *inline) bool {
+	p.Pos++
+	kind := SoftBreak
+	if last := block.LastChild; last != nil && last.Kind == Text && strings.HasSuffix(last.Literal, " ") {
+		if strings.HasSuffix(last.Literal, "  ") {
+			kind = LineBreak
+		}
+		last.Literal = strings.TrimRight(last.Literal, " ")
+	}
+	block.appendChild(&inline{
+		Kind: kind,
+	})
+	// Skip the leading spaces of the next line.
+	for p.peek() == ' ' {
+		p.Pos++
+	}
+	return true
+}
+
+func (p *inlineParser) parseBackslash(block *inline) bool {
+	p.Pos++
+	switch c := p.peek(); {
+	case c == '\n':
+		p.Pos++
+		block.appendChild(&inline{
+			Kind: LineBreak,
+		})
+	case isASCIIPunct(c):
+		block.appendChild(text(string(c)))
+		p.Pos++
+	default:
+		block.appendChild(text(`\`))
+	}
+	return true
+}
+
+func (p *inlineParser) parseBackticks(block *inline) bool {
+	start := p.Pos
+	for p.peek() == '`' {
+		p.Pos++
+	}
+	ticks := p.Pos - start
+	afterOpenTicks := p.Pos
+	for {
+		i := strings.IndexByte(p.Subject[p.Pos:], '`')
+		if i == -1 {
+			break
+		}
+		runStart := p.Pos + i
+		p.Pos = runStart
+		for p.peek() == '`' {
+			p.Pos++
+		}
+		if p.Pos-runStart != ticks {
+			continue
+		}
+		contents := strings.ReplaceAll(p.Subject[afterOpenTicks:runStart], "\n", " ")
+		if len(contents) > 2 && contents[0] == ' ' && contents[len(contents)-1] == ' ' && strings.Trim(contents, " ") != "" {
+			contents = contents[1 : len(contents)-1]
+		}
+		block.appendChild(&inline{
+			Kind:    Code,
+			Literal: contents,
+		})
+		return true
+	}
+	p.Pos = afterOpenTicks
+	block.appendChild(text(p.Subject[start:afterOpenTicks]))
+	return true
+}
+
+// scanDelims returns the length of the delimiter run at the current
+// position, and whether it can open or close emphasis.
+func (p *inlineParser) scanDelims(c byte) (count int, canOpen, canClose bool) {
+	for peek(p.Subject, p.Pos+count) == c {
+		count++
+	}
+	before, after := '\n', '\n'
+	if p.Pos > 0 {
+		before, _ = utf8.DecodeLastRuneInString(p.Subject[:p.Pos])
+	}
+	if p.Pos+count < len(p.Subject) {
+		after, _ = utf8.DecodeRuneInString(p.Subject[p.Pos+count:])
+	}
+	beforeSpace, beforePunct := isUnicodeSpace(before), isPunct(before)
+	afterSpace, afterPunct := isUnicodeSpace(after), isPunct(after)
+
+	leftFlanking := !afterSpace && (!afterPunct || beforeSpace || beforePunct)
+	rightFlanking := !beforeSpace && (!beforePunct || afterSpace || afterPunct)
+	if c == '_' {
+		canOpen = leftFlanking && (!rightFlanking || beforePunct)
+		canClose = rightFlanking && (!leftFlanking || afterPunct)
+	} else {
+		canOpen = leftFlanking
+		canClose = rightFlanking
+	}
+	return count, canOpen, canClose
+}
+
+func (p *inlineParser) parseDelimiter(c byte, block *inline) bool {
+	count, canOpen, canClose := p.scanDelims(c)
+	node := text(p.Subject[p.Pos : p.Pos+count])
+	p.Pos += count
+	block.appendChild(node)
+	if canOpen || canClose {
+		d := &delimiter{
+			Char:          c,
+			Count:         count,
+			OriginalCount: count,
+			Node:          node,
+			Prev:          p.Delimiters,
+			CanOpen:       canOpen,
+			CanClose:      canClose,
+		}
+		if d.Prev != nil {
+			d.Prev.Next = d
+		}
+		p.Delimiters = d
+	}
+	return true
+}
+
+func (p *inlineParser) removeDelimiter(d *delimiter) {
+	if d.Prev != nil {
+		d.Prev.Next = d.Next
+	}
+	if d.Next != nil {
+		d.Next.Prev = d.Prev
+	} else {
+		p.Delimiters = d.Prev
+	}
+}
+
+// processEmphasis matches the delimiters above stackBottom, and replaces
+// them with emphasis.
+func (p *inlineParser) processEmphasis(stackBottom *delimiter) {
+	// openersBottom is the lowest delimiter to look for openers at, by the
+	// character, whether the closer can open, and the length of the closer
+	// modulo 3.
+	var openersBottom [12]*delimiter
+	for i := range openersBottom {
+		openersBottom[i] = stackBottom
+	}
+
+	closer := p.Delimiters
+	for closer != nil && closer.Prev != stackBottom {
+		closer = closer.Prev
+	}
+	for closer != nil {
+		if !closer.CanClose {
+			closer = closer.Next
+			continue
+		}
+
+		index := closer.OriginalCount % 3
+		if closer.CanOpen {
+			index += 3
+		}
+		if closer.Char == '*' {
+			index += 6
+		}
+		opener := closer.Prev
+		found := false
+		for opener != nil && opener != stackBottom && opener != openersBottom[index] {
+			oddMatch := (closer.CanOpen || opener.CanClose) && closer.OriginalCount%3 != 0 && (opener.OriginalCount+closer.OriginalCount)%3 == 0
+			if opener.Char == closer.Char && opener.CanOpen && !oddMatch {
+				found = true
+				break
+			}
+			opener = opener.Prev
+		}
+		if !found {
+			openersBottom[index] = closer.Prev
+			next := closer.Next
+			if !closer.CanOpen {
+				p.removeDelimiter(closer)
+			}
+			closer = next
+			continue
+		}
+
+		used := 1
+		kind := Emphasis
+		if closer.Count >= 2 && opener.Count >= 2 {
+			used = 2
+			kind = Strong
+		}
+		opener.Count -= used
+		closer.Count -= used
+		openerNode, closerNode := opener.Node, closer.Node
+		openerNode.Literal = openerNode.Literal[:len(openerNode.Literal)-used]
+		closerNode.Literal = closerNode.Literal[:len(closerNode.Literal)-used]
+
+		emphasis := &inline{
+			Kind: kind,
+		}
+		for n := openerNode.Next; n != nil && n != closerNode; {
+			next := n.Next
+			emphasis.appendChild(n)
+			n = next
+		}
+		openerNode.insertAfter(emphasis)
+
+		// Remove the delimiters between the opener and closer.
+		if opener.Next != closer {
+			opener.Next = closer
+			closer.Prev = opener
+		}
+		if opener.Count == 0 {
+			openerNode.unlink()
+			p.removeDelimiter(opener)
+		}
+		if closer.Count == 0 {
+			closerNode.unlink()
+			next := closer.Next
+			p.removeDelimiter(closer)
+			closer = next
+		}
+	}
+
+	for p.Delimiters != nil && p.Delimiters != stackBottom {
+		p.removeDelimiter(p.Delimiters)
+	}
+}
+
+func (p *inlineParser) addBracket(node *inline, index int, image bool) {
+	if p.Brackets != nil {
+		p.Brackets.BracketAfter = true
+	}
+	p.Brackets = &bracket{
+		Node:          node,
+		Prev:          p.Brackets,
+		PrevDelimiter: p.Delimiters,
+		Index:         index,
+		Image:         image,
+		Active:        true,
+	}
+}
+
+func (p *inlineParser) parseOpenBracket(block *inline) bool {
+	node := text("[")
+	block.appendChild(node)
+	p.addBracket(node, p.Pos, false)
+	p.Pos++
+	return true
+}
+
+func (p *inlineParser) parseBang(block *inline) bool {
+	p.Pos++
+	if p.peek() != '[' {
+		block.appendChild(text("!"))
+		return true
+	}
+	node := text("![")
+	block.appendChild(node)
+	p.addBracket(node, p.Pos, true)
+	p.Pos++
+	return true
+}
+
+func (p *inlineParser) parseCloseBracket(block *inline) bool {
+	p.Pos++
+	startPos := p.Pos
+
+	opener := p.Brackets
+	if opener == nil {
+		block.appendChild(text("]"))
+		return true
+	}
+	if !opener.Active {
+		block.appendChild(text("]"))
+		p.Brackets = opener.Prev
+		return true
+	}
+
+	var destination, title string
+	matched := false
+	savePos := p.Pos
+
+	// Inline link.
+	if p.peek() == '(' {
+		p.Pos++
+		p.spnl()
+		if d, ok := p.parseLinkDestination(); ok {
+			destination = d
+			p.spnl()
+			if isWhitespace(p.Subject[p.Pos-1]) {
+				title, _ = p.parseLinkTitle()
+			}
+			p.spnl()
+			if p.peek() == ')' {
+				p.Pos++
+				matched = true
+			}
+		}
+		if !matched {
+			p.Pos = savePos
+		}
+	}
+
+	// Reference link.
+	if !matched {
+		beforeLabel := p.Pos
+		n := p.parseLinkLabel()
+		var label string
+		if n > 2 {
+			label = p.Subject[beforeLabel : beforeLabel+n]
+		} else if !opener.BracketAfter {
+			// An empty or missing label uses the text of the link, which
+			// cannot contain brackets.
+			label = p.Subject[opener.Index:startPos]
+		}
+		if n == 0 {
+			p.Pos = savePos
+		}
+		if ref, ok := p.Refs[normalizeReference(label)]; ok && label != "" {
+			destination = ref.Destination
+			title = ref.Title
+			matched = true
+		}
+	}
+
+	if !matched {
+		p.Brackets = opener.Prev
+		p.Pos = startPos
+		block.appendChild(text("]"))
+		return true
+	}
+
+	kind := Link
+	if opener.Image {
+		kind = Image
+	}
+	node := &inline{
+		Kind:        kind,
+		Destination: destination,
+		Title:       title,
+	}
+	for n := opener.Node.Next; n != nil; {
+		next := n.Next
+		node.appendChild(n)
+		n = next
+	}
+	block.appendChild(node)
+	p.processEmphasis(opener.PrevDelimiter)
+	p.Brackets = opener.Prev
+	opener.Node.unlink()
+
+	// Links cannot contain other links, so earlier link openers are
+	// deactivated.
+	if !opener.Image {
+		for b := p.Brackets; b != nil; b = b.Prev {
+			if !b.Image {
+				b.Active = false
+			}
+		}
+	}
+	return true
+}
+
+var spnl = regexp.MustCompile(`^ *(?:\n *)?`)
+
+// spnl skips spaces and at most one line break.
+func (p *inlineParser) spnl() {
+	p.match(spnl)
+}
+
+var linkDestinationBraces = regexp.MustCompile(`^<(?:[^<>\n\\\x00]|\\.)*>`)
+
+func (p *inlineParser) parseLinkDestination() (string, bool) {
+	if s, ok := p.match(linkDestinationBraces); ok {
+		return normalizeURI(unescapeString(s[1 : len(s)-1])), true
+	}
+	if p.peek() == '<' {
+		return "", false
+	}
+	start := p.Pos
+	parens := 0
+	for p.Pos < len(p.Subject) {
+		c := p.peek()
+		if c == '\\' && isASCIIPunct(peek(p.Subject, p.Pos+1)) {
+			p.Pos += 2
+		} else if c == '(' {
+			p.Pos++
+			parens++
+		} else if c == ')' {
+			if parens < 1 {
+				break
+			}
+			p.Pos++
+			parens--
+		} else if isWhitespace(c) || c < 0x20 || c == 0x7f {
+			break
+		} else {
+			p.Pos++
+		}
+	}
+	if (p.Pos == start && p.peek() != ')') || parens != 0 {
+		p.Pos = start
+		return "", false
+	}
+	return normalizeURI(unescapeString(p.Subject[start:p.Pos])), true
+}
+
+func (p *inlineParser) parseLinkTitle() (string, bool) {
+	closing := p.peek()
+	switch closing {
+	case '"', '\'':
+	case '(':
+		closing = ')'
+	default:
+		return "", false
+	}
+	for i := p.Pos + 1; i < len(p.Subject); i++ {
+		switch c := p.Subject[i]; {
+		case c == '\\':
+			i++
+		case c == closing:
+			title := p.Subject[p.Pos+1 : i]
+			p.Pos = i + 1
+			return unescapeString(title), true
+		case c == '(' && closing == ')':
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// parseLinkLabel advances past a link label and returns its length, or 0 if
+// there is none.
+func (p *inlineParser) parseLinkLabel() int {
+	if p.peek() != '[' {
+		return 0
+	}
+	for i := p.Pos + 1; i < len(p.Subject); i++ {
+		switch p.Subject[i] {
+		case '\\':
+			i++
+		case '[':
+			return 0
+		case ']':
+			n := i + 1 - p.Pos
+			if utf8.RuneCountInString(p.Subject[p.Pos:i+1]) > 1001 {
+				return 0
+			}
+			p.Pos = i + 1
+			return n
+		}
+	}
+	return 0
+}
+
+var (
+	emailAutolink = regexp.MustCompile("^<([a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>")
+	autolink      = regexp.MustCompile(`(?i)^<[A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*>`)
+)
+
+func (p *inlineParser) parseAutolink(block *inline) bool {
+	var destination string
+	s, ok := p.match(emailAutolink)
+	if ok {
+		s = s[1 : len(s)-1]
+		destination = "mailto:" + s
+	} else if s, ok = p.match(autolink); ok {
+		s = s[1 : len(s)-1]
+		destination = s
+	} else {
+		return false
+	}
+	link := &inline{
+		Kind:        Link,
+		Destination: normalizeURI(destination),
+	}
+	link.appendChild(text(s))
+	block.appendChild(link)
+	return true
+}
+
+var htmlTagPattern = regexp.MustCompile(`(?i)^` + htmlTag)
+
+func (p *inlineParser) parseHTMLTag(block *inline) bool {
+	s, ok := p.match(htmlTagPattern)
+	if !ok {
+		return false
+	}
+	block.appendChild(&inline{
+		Kind:    HTML,
+		Literal: s,
+	})
+	return true
+}
+
+const entity = `&(?:#[xX][a-fA-F0-9]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`
+
+var entityPattern = regexp.MustCompile(`^` + entity)
+
+func (p *inlineParser) parseEntity(block *inline) bool {
+	s, ok := p.match(entityPattern)
+	if !ok {
+		return false
+	}
+	block.appendChild(text(decodeEntity(s)))
+	return true
+}
+
+// decodeEntity decodes an entity that matches the entity pattern. Unknown
+// named entities are returned unchanged.
+func decodeEntity(s string) string {
+	decoded := html.UnescapeString(s)
+	if strings.HasSuffix(decoded, ";") {
+		// html.UnescapeString decodes the longest prefix of unknown names
+		// that is an entity (e.g. &ampx; as &x;).
+		return s
+	}
+	return decoded
+}
+
+var entityOrEscapedChar = regexp.MustCompile(`\\[!"#$%&'()*+,./:;<=>?@[\\\]^_` + "`" + `{|}~-]|` + entity)
+
+// unescapeString replaces the backslash escapes and entities of s.
+func unescapeString(s string) string {
+	return entityOrEscapedChar.ReplaceAllStringFunc(s, func(s string) string {
+		if s[0] == '\\' {
+			return s[1:]
+		}
+		return decodeEntity(s)
+	})
+}
+
+var spaceAtEndOfLine = regexp.MustCompile(`^ *(?:\n|$)`)
+
+func (p *inlineParser) parseReference(refs map[string]reference) int {
+	n := p.parseLinkLabel()
+	if n == 0 {
+		return 0
+	}
+	label := p.Subject[:n]
+	if p.peek() != ':' {
+		return 0
+	}
+	p.Pos++
+
+	p.spnl()
+	destination, ok := p.parseLinkDestination()
+	if !ok {
+		return 0
+	}
+
+	beforeTitle := p.Pos
+	p.spnl()
+	title, hasTitle := "", false
+	if p.Pos != beforeTitle {
+		title, hasTitle = p.parseLinkTitle()
+	}
+	if !hasTitle {
+		p.Pos = beforeTitle
+	}
+	if _, ok := p.match(spaceAtEndOfLine); !ok {
+		if !hasTitle {
+			return 0
+		}
+		// The title is not at the end of the line, but the destination may
+		// be.
+		title = ""
+		p.Pos = beforeTitle
+		if _, ok := p.match(spaceAtEndOfLine); !ok {
+			return 0
+		}
+	}
+
+	key := normalizeReference(label)
+	if key == "" {
+		return 0
+	}
+	if _, ok := refs[key]; !ok {
+		refs[key] = reference{
+			Destination: destination,
+			Title:       title,
+		}
+	}
+	return p.Pos
+}
+
+var whitespaceRun = regexp.MustCompile(`[ \t\r\n]+`)
+
+// normalizeReference returns the key of a link label for case-insensitive
+// matching.
+func normalizeReference(label string) string {
+	if len(label) < 2 {
+		return ""
+	}
+	label = strings.TrimSpace(label[1 : len(label)-1])
+	label = whitespaceRun.ReplaceAllString(label, " ")
+	// Case folding maps ß to ss, which ToUpper does not.
+	label = strings.ReplaceAll(strings.ToLower(label), "ß", "ss")
+	return strings.ToUpper(label)
+}
+
+const uriSafeChars = ";/?:@&=+$,-_.!~*'()#"
+
+// normalizeURI percent-encodes the characters of a URI that are not allowed
+// in URIs, keeping existing escapes.
+func normalizeURI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteString(s[i : i+3])
+			i += 2
+		case c < utf8.RuneSelf && (isAlphanumeric(c) || strings.IndexByte(uriSafeChars, c) != -1):
+			b.WriteByte(c)
+		default:
+			const hex = "0123456789ABCDEF"
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return c != 0 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) != -1
+}
+
+func isPunct(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isASCIIPunct(byte(r))
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isUnicodeSpace(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\f' || r == '\r' || unicode.Is(unicode.Zs, r)
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func isAlphanumeric(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
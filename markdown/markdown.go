@@ -0,0 +1,308 @@
+// Package markdown converts Markdown documents to m elements.
+//
+// Documents are parsed according to the CommonMark specification, with the
+// table extension of GitHub Flavored Markdown:
+//
+//	m.M("article", markdown.Convert(source))
+//
+// How each kind of node is converted can be customized, such as to add the
+// classes of a CSS framework:
+//
+//	c := markdown.Converter{
+//		Render: map[markdown.Kind]func(*markdown.Node, m.Element) m.Element{
+//			markdown.Table: func(n *markdown.Node, children m.Element) m.Element {
+//				return m.M("table.table", children)
+//			},
+//		},
+//	}
+//	el := c.Convert(source)
+//
+// Render does the reverse, writing an element as Markdown, such as to
+// generate a README or chat message from the same elements as a web page.
+package markdown
+
+import (
+	"strconv"
+	"strings"
+
+	"layeh.com/m"
+)
+
+// Kind is the kind of a Node.
+type Kind int
+
+// Node kinds. Document to TableCell are blocks, and the rest are inlines.
+const (
+	Document Kind = iota + 1
+	BlockQuote
+	List
+	Item
+	CodeBlock
+	HTMLBlock
+	Paragraph
+	Heading
+	ThematicBreak
+	// Table contains a TableHead with the header row, and a TableBody with
+	// the other rows if there are any.
+	Table
+	TableHead
+	TableBody
+	TableRow
+	TableCell
+
+	Text
+	SoftBreak
+	LineBreak
+	Code
+	HTML
+	Emphasis
+	Strong
+	Link
+	Image
+)
+
+var kindNames = [...]string{
+	Document:      "document",
+	BlockQuote:    "block-quote",
+	List:          "list",
+	Item:          "item",
+	CodeBlock:     "code-block",
+	HTMLBlock:     "html-block",
+	Paragraph:     "paragraph",
+	Heading:       "heading",
+	ThematicBreak: "thematic-break",
+	Table:         "table",
+	TableHead:     "table-head",
+	TableBody:     "table-body",
+	TableRow:      "table-row",
+	TableCell:     "table-cell",
+	Text:          "text",
+	SoftBreak:     "soft-break",
+	LineBreak:     "line-break",
+	Code:          "code",
+	HTML:          "html",
+	Emphasis:      "emphasis",
+	Strong:        "strong",
+	Link:          "link",
+	Image:         "image",
+}
+
+func (k Kind) String() string {
+	if k > 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Align is the alignment of a table column.
+type Align int
+
+// Column alignments.
+const (
+	AlignNone Align = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Node is a node of a parsed Markdown document.
+type Node struct {
+	Kind     Kind
+	Children []*Node
+
+	// Literal is the content of Text, Code, CodeBlock, HTMLBlock and HTML
+	// nodes.
+	Literal string
+
+	// Level is the level of a Heading, from 1 to 6.
+	Level int
+
+	// Ordered, Start and Tight are set for List. The paragraphs of the
+	// items of a tight list are not wrapped in p elements.
+	Ordered bool
+	Start   int
+	Tight   bool
+
+	// Info is the info string of a fenced CodeBlock (e.g. the language).
+	Info string
+
+	// Destination and Title are set for Link and Image.
+	Destination string
+	Title       string
+
+	// Header and Align are set for TableCell.
+	Header bool
+	Align  Align
+}
+
+// Text returns the concatenated text of n and its descendants, such as for
+// the alt text of an image.
+func (n *Node) Text() string {
+	var b strings.Builder
+	n.writeText(&b)
+	return b.String()
+}
+
+func (n *Node) writeText(b *strings.Builder) {
+	switch n.Kind {
+	case Text, Code:
+		b.WriteString(n.Literal)
+	case SoftBreak, LineBreak:
+		b.WriteByte('\n')
+	default:
+		for _, child := range n.Children {
+			child.writeText(b)
+		}
+	}
+}
+
+// Parse parses a Markdown document and returns its Document node.
+func Parse(source string) *Node {
+	var p parser
+	return p.parse(source)
+}
+
+// Convert parses a Markdown document and converts it to an element, using
+// the default conversion of each node.
+//
+// The document is trusted: raw HTML is passed through, and the destinations
+// of links and images are used unchanged, including javascript: URLs. Use a
+// Converter with EscapeHTML and Render functions for Link and Image to
+// convert untrusted documents.
+func Convert(source string) m.Element {
+	var c Converter
+	return c.Convert(source)
+}
+
+// Converter converts Markdown documents to elements.
+//
+// As with Convert, the destinations of links and images are used unchanged
+// unless Render functions are given for Link and Image.
+type Converter struct {
+	// Render overrides how nodes of a kind are converted. The function is
+	// called with the node and its converted children, which is nil if the
+	// node has none. Kinds that are not in Render use Default.
+	//
+	// Paragraphs of tight lists are not converted, as their children are
+	// added to the item directly.
+	Render map[Kind]func(n *Node, children m.Element) m.Element
+
+	// EscapeHTML converts raw HTML to text instead of passing it through,
+	// such as for untrusted documents. The URLs of links and images are not
+	// restricted, which requires Render functions for Link and Image.
+	EscapeHTML bool
+}
+
+// Convert parses a Markdown document and converts it to an element.
+func (c *Converter) Convert(source string) m.Element {
+	return c.Element(Parse(source))
+}
+
+// Element converts n and its descendants to an element.
+func (c *Converter) Element(n *Node) m.Element {
+	return c.element(n, false)
+}
+
+// element converts n, which is an item of a tight list if tight is true.
+func (c *Converter) element(n *Node, tight bool) m.Element {
+	var children []m.Element
+	for _, child := range n.Children {
+		if tight && child.Kind == Paragraph {
+			for _, inline := range child.Children {
+				children = append(children, c.element(inline, false))
+			}
+			continue
+		}
+		children = append(children, c.element(child, n.Kind == List && n.Tight))
+	}
+	if render, ok := c.Render[n.Kind]; ok {
+		return render(n, m.S(children...))
+	}
+	if c.EscapeHTML && (n.Kind == HTML || n.Kind == HTMLBlock) {
+		return m.T(n.Literal)
+	}
+	return Default(n, m.S(children...))
+}
+
+// Default returns the default conversion of n, given its converted
+// children.
+func Default(n *Node, children m.Element) m.Element {
+	switch n.Kind {
+	case Document:
+		return children
+	case BlockQuote:
+		return m.M("blockquote", children)
+	case List:
+		if n.Ordered {
+			return m.M("ol",
+				m.If(n.Start != 1, m.Attr("start", strconv.Itoa(n.Start))),
+				children,
+			)
+		}
+		return m.M("ul", children)
+	case Item:
+		return m.M("li", children)
+	case CodeBlock:
+		var class m.Element
+		if language := strings.Fields(n.Info); len(language) > 0 {
+			class = m.Attr("class", "language-"+language[0])
+		}
+		return m.M("pre", m.M("code", class, m.T(n.Literal)))
+	case HTMLBlock, HTML:
+		return m.Raw(n.Literal)
+	case Paragraph:
+		return m.M("p", children)
+	case Heading:
+		return m.M("h"+strconv.Itoa(n.Level), children)
+	case ThematicBreak:
+		return m.M("hr")
+	case Table:
+		return m.M("table", children)
+	case TableHead:
+		return m.M("thead", children)
+	case TableBody:
+		return m.M("tbody", children)
+	case TableRow:
+		return m.M("tr", children)
+	case TableCell:
+		tagName := "td"
+		if n.Header {
+			tagName = "th"
+		}
+		return m.M(tagName,
+			m.If(n.Align != AlignNone, m.Attr("align", alignNames[n.Align])),
+			children,
+		)
+	case Text:
+		return m.T(n.Literal)
+	case SoftBreak:
+		return m.T("\n")
+	case LineBreak:
+		return m.S(m.M("br"), m.T("\n"))
+	case Code:
+		return m.M("code", m.T(n.Literal))
+	case Emphasis:
+		return m.M("em", children)
+	case Strong:
+		return m.M("strong", children)
+	case Link:
+		return m.M("a",
+			m.Attr("href", n.Destination),
+			m.If(n.Title != "", m.Attr("title", n.Title)),
+			children,
+		)
+	case Image:
+		return m.M("img",
+			m.Attr("src", n.Destination),
+			m.Attr("alt", n.Text()),
+			m.If(n.Title != "", m.Attr("title", n.Title)),
+		)
+	}
+	return children
+}
+
+var alignNames = [...]string{
+	AlignLeft:   "left",
+	AlignCenter: "center",
+	AlignRight:  "right",
+}
@@ -0,0 +1,196 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"layeh.com/m"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		Markdown string
+		Expected string
+	}{
+		{"", ""},
+		{"Hello *world*", `<p>Hello <em>world</em></p>`},
+		{"# Title #\n\nSub\n---", `<h1>Title</h1><h2>Sub</h2>`},
+		{"a\nb  \nc\\\nd", "<p>a\nb<br>\nc<br>\nd</p>"},
+		{"***strong emph***", `<p><em><strong>strong emph</strong></em></p>`},
+		{"*foo**bar**baz*", `<p><em>foo<strong>bar</strong>baz</em></p>`},
+		{"foo_bar_", `<p>foo_bar_</p>`},
+		{"`` a ` b ``", "<p><code>a ` b</code></p>"},
+		{`\*not emphasized\*`, `<p>*not emphasized*</p>`},
+		{"&copy; &amp; &ampx; &#35;", `<p>© &amp; &amp;ampx; #</p>`},
+		{"- a\n- b\n\n1. c\n2. d", `<ul><li>a</li><li>b</li></ul><ol><li>c</li><li>d</li></ol>`},
+		{"- a\n\n- b", `<ul><li><p>a</p></li><li><p>b</p></li></ul>`},
+		{"3) a\n   - b\n     c", `<ol start="3"><li>a<ul><li>b
+c</li></ul></li></ol>`},
+		{"> quote\nlazy\n> > nested", "<blockquote><p>quote\nlazy</p><blockquote><p>nested</p></blockquote></blockquote>"},
+		{"    code\n\n    more", "<pre><code>code\n\nmore\n</code></pre>"},
+		{"```go extra\nfunc() {}\n```", "<pre><code class=\"language-go\">func() {}\n</code></pre>"},
+		{"~~~\n<a>\n", "<pre><code>&lt;a&gt;\n</code></pre>"},
+		{"***\n- - -", `<hr><hr>`},
+		{"[link](/url \"title\") [empty]()", `<p><a href="/url" title="title">link</a> <a href="">empty</a></p>`},
+		{"[a][Ref] [ref][] [REF]\n\n[ref]: /url 'T'", `<p><a href="/url" title="T">a</a> <a href="/url" title="T">ref</a> <a href="/url" title="T">REF</a></p>`},
+		{"[not a link]\n[a [nested](/x)](/y)", "<p>[not a link]\n[a <a href=\"/x\">nested</a>](/y)</p>"},
+		{"![alt *text*](/img.png)", `<p><img src="/img.png" alt="alt text"></p>`},
+		{"<https://example.com/?a=b> <me@example.com>", `<p><a href="https://example.com/?a=b">https://example.com/?a=b</a> <a href="mailto:me@example.com">me@example.com</a></p>`},
+		{"[space](</a b>)", `<p><a href="/a%20b">space</a></p>`},
+		{"<div>\n*raw*\n</div>\n\n<span>*inline*</span>", "<div>\n*raw*\n</div><p><span><em>inline</em></span></p>"},
+		{"\tfoo", "<pre><code>foo\n</code></pre>"},
+		{"| a | b |\n|:--|--:|\n| 1 | `\\|` |\n| 2", `<table><thead><tr><th align="left">a</th><th align="right">b</th></tr></thead><tbody><tr><td align="left">1</td><td align="right"><code>|</code></td></tr><tr><td align="left">2</td><td align="right"></td></tr></tbody></table>`},
+		{"para\n| a |\n| - |", `<p>para</p><table><thead><tr><th>a</th></tr></thead></table>`},
+		{"| a | b |\n| - |", "<p>| a | b |\n| - |</p>"},
+		// URLs are not sanitized.
+		{"[x](javascript:alert(1)) ![y](javascript:alert(2))", `<p><a href="javascript:alert(1)">x</a> <img src="javascript:alert(2)" alt="y"></p>`},
+	}
+
+	for _, tt := range tests {
+		if got := m.RenderString(Convert(tt.Markdown)); got != tt.Expected {
+			t.Errorf("%q: got %#v; expected %#v", tt.Markdown, got, tt.Expected)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	c := Converter{
+		Render: map[Kind]func(n *Node, children m.Element) m.Element{
+			Table: func(n *Node, children m.Element) m.Element {
+				return m.M("table.table", children)
+			},
+			Link: func(n *Node, children m.Element) m.Element {
+				return m.M("a", m.Attr("href", n.Destination), m.Attr("rel", "nofollow"), children)
+			},
+			Heading: func(n *Node, children m.Element) m.Element {
+				return Default(&Node{Kind: Heading, Level: n.Level + 1}, children)
+			},
+			Paragraph: func(n *Node, children m.Element) m.Element {
+				return m.M("p.lead", children)
+			},
+		},
+		EscapeHTML: true,
+	}
+	tests := []struct {
+		Markdown string
+		Expected string
+	}{
+		{"a | b\n--|--", `<table class="table"><thead><tr><th>a</th><th>b</th></tr></thead></table>`},
+		{"[x](/y)", `<p class="lead"><a href="/y" rel="nofollow">x</a></p>`},
+		{"# Title", `<h2>Title</h2>`},
+		// Paragraphs of tight lists are not converted.
+		{"- a\n- b", `<ul><li>a</li><li>b</li></ul>`},
+		{"<script>alert(1)</script>\n\n<b>x</b>", `&lt;script&gt;alert(1)&lt;/script&gt;<p class="lead">&lt;b&gt;x&lt;/b&gt;</p>`},
+		// EscapeHTML does not sanitize URLs either.
+		{"![y](javascript:alert(2))", `<p class="lead"><img src="javascript:alert(2)" alt="y"></p>`},
+	}
+
+	for _, tt := range tests {
+		if got := m.RenderString(c.Convert(tt.Markdown)); got != tt.Expected {
+			t.Errorf("%q: got %#v; expected %#v", tt.Markdown, got, tt.Expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc := Parse("1. *a*\n2. b")
+	if doc.Kind != Document || len(doc.Children) != 1 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	list := doc.Children[0]
+	if list.Kind != List || !list.Ordered || list.Start != 1 || !list.Tight || len(list.Children) != 2 {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	if text := list.Text(); text != "ab" {
+		t.Errorf("expected text %q, got %q", "ab", text)
+	}
+	emphasis := list.Children[0].Children[0].Children[0]
+	if emphasis.Kind != Emphasis || emphasis.Kind.String() != "emphasis" {
+		t.Errorf("unexpected node %+v", emphasis)
+	}
+}
+
+func TestRender(t *testing.T) {
+	el := m.Document(m.M("html",
+		m.M("head", m.M("title", m.T("Welcome")), m.M("style", m.Raw("p { color: red; }"))),
+		m.M("body",
+			m.M("h1", m.T("Welcome,  Alice")),
+			m.M("p", m.T("Thanks for\n signing "), m.M("strong", m.T("up! ")), m.M("a[href=https://example.com/confirm][title=Confirm it]", m.T("Confirm")), m.T(" your email.")),
+			m.M("ul",
+				m.M("li", m.T("One"), m.M("br"), m.T("line")),
+				m.M("li", m.T("Two"), m.M("ol[start=3]", m.M("li", m.T("Three")), m.M("li", m.T("Four")))),
+			),
+			m.M("blockquote", m.M("p", m.T("Quote 1")), m.M("pre", m.M("code.language-go", m.T("a := 1\n\nb := 2\n")))),
+			m.M("table",
+				m.M("tr", m.M("th", m.T("Item")), m.M("th[align=right]", m.T("Price"))),
+				m.M("tr", m.M("td", m.T("Widget | Gadget")), m.M("td", m.M("em", m.T("$1.00")))),
+			),
+			m.M("p", m.Raw("Tom &amp; <b>Jerry</b>"), m.M("img[src=/logo.png][alt=Logo]"), m.M("svg", m.M("text", m.T("Vector")))),
+			m.M("hr"),
+			m.M("p", m.M("a[href=https://example.com/]", m.T("https://example.com/")), m.T(" "), m.M("code", m.T("a`b"))),
+		),
+	))
+	expected := `# Welcome, Alice
+
+Thanks for signing **up!** [Confirm](https://example.com/confirm "Confirm it") your email.
+
+- One\
+  line
+- Two
+  3. Three
+  4. Four
+
+> Quote 1
+>
+> ` + "```go" + `
+> a := 1
+>
+> b := 2
+> ` + "```" + `
+
+| Item             | Price   |
+| ---------------- | ------: |
+| Widget \| Gadget | *$1.00* |
+
+Tom & Jerry ![Logo](/logo.png)Vector
+
+***
+
+<https://example.com/> ` + "``a`b``" + `
+`
+	var b strings.Builder
+	if err := Render(&b, el); err != nil {
+		t.Fatal(err)
+	}
+	if output := b.String(); output != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestRender_escape(t *testing.T) {
+	tests := []struct {
+		Element  m.Element
+		Expected string
+	}{
+		{m.T("*a* _b_ snake_case [c] `d` <e> \\"), "\\*a\\* \\_b\\_ snake_case \\[c\\] \\`d\\` \\<e> \\\\\n"},
+		{m.T("# a\n- b"), "\\# a - b\n"},
+		{m.M("p", m.T("1. a")), "1\\. a\n"},
+		{m.T("&copy; ~~x~~ ~y"), "\\&copy; \\~\\~x\\~\\~ ~y\n"},
+		{m.S(m.T("!"), m.M("a[href=/a b]", m.T("link"))), "\\![link](</a b>)\n"},
+		{m.M("h2", m.T("C#")), "## C\\#\n"},
+		{m.M("a[href=/x]"), "[](/x)\n"},
+		{m.M("ul", m.M("li", m.M("ul", m.M("li", m.T("a"))))), "- - a\n"},
+		{m.M("pre"), "```\n```\n"},
+		{m.M("script", m.T("alert(1)")), ""},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := Render(&b, tt.Element); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tt.Expected {
+			t.Errorf("%s: got %#v; expected %#v", m.RenderString(tt.Element), got, tt.Expected)
+		}
+	}
+}
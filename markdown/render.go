@@ -0,0 +1,516 @@
+package markdown
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"layeh.com/m"
+	"layeh.com/m/internal/lines"
+)
+
+// Render writes element to w as Markdown.
+//
+// Elements that Markdown can represent are converted to their Markdown
+// syntax, with tables written as GitHub Flavored Markdown tables. Other
+// elements degrade to their content: block elements are written as
+// paragraphs, and inline elements as text. Tags are removed from raw HTML,
+// and the contents of elements such as script and style are omitted.
+//
+// Text is escaped so that it does not form Markdown syntax, and whitespace
+// is collapsed outside of pre elements.
+//
+// A non-nil error is returned if the element could not be successfully written.
+func Render(w io.Writer, element m.Element) error {
+	nodes, err := m.Nodes(element)
+	if err != nil {
+		return err
+	}
+	var mw markdownWriter
+	mw.HardBreak = `\`
+	mw.nodes(nodes)
+	if mw.B.Len() > 0 {
+		mw.B.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, mw.B.String())
+	return err
+}
+
+// markdownEmphasis are the delimiters of inline elements.
+var markdownEmphasis = map[string]string{
+	"b":      "**",
+	"del":    "~~",
+	"em":     "*",
+	"i":      "*",
+	"s":      "~~",
+	"strong": "**",
+}
+
+type markdownWriter struct {
+	lines.Writer
+
+	// cell is whether the content is in a table cell, where pipes are
+	// escaped.
+	cell  bool
+	lists int
+}
+
+// text writes s escaped, with whitespace collapsed.
+func (w *markdownWriter) text(s string) {
+	w.Words(s, func(word string) string {
+		word = escapeMarkdown(word)
+		if w.cell {
+			word = strings.ReplaceAll(word, "|", `\|`)
+		}
+		if w.AtLineStart() {
+			word = escapeLineStart(word)
+		}
+		return word
+	})
+}
+
+// escapeMarkdown escapes the characters of s that could form inline
+// Markdown syntax.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '`', '*', '[', ']', '<':
+			b.WriteByte('\\')
+		case '~':
+			// Runs of tildes form strikethrough and code fences.
+			if peek(s, i+1) == '~' || i > 0 && s[i-1] == '~' {
+				b.WriteByte('\\')
+			}
+		case '_':
+			// Underscores inside of words do not form emphasis.
+			if i == 0 || i == len(s)-1 || !isAlphanumeric(s[i-1]) || !isAlphanumeric(s[i+1]) {
+				b.WriteByte('\\')
+			}
+		case '&':
+			if entityPattern.MatchString(s[i:]) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+var orderedMarker = regexp.MustCompile(`^[0-9]{1,9}[.)]`)
+
+// escapeLineStart escapes s if it would start a block at the start of a
+// line (e.g. a heading or list item).
+func escapeLineStart(s string) string {
+	switch s[0] {
+	case '#', '>', '+', '-', '=':
+		return `\` + s
+	}
+	if loc := orderedMarker.FindStringIndex(s); loc != nil {
+		return s[:loc[1]-1] + `\` + s[loc[1]-1:]
+	}
+	return s
+}
+
+func (w *markdownWriter) nodes(nodes []*m.Node) {
+	for _, n := range nodes {
+		switch n.Type {
+		case m.TextNode:
+			w.text(n.Data)
+		case m.RawNode:
+			w.text(lines.RawText(n.Data))
+		case m.ElementNode:
+			w.element(n)
+		}
+	}
+}
+
+// inlineContent returns the Markdown of nodes as a single line, and whether
+// it has leading and trailing whitespace.
+func (w *markdownWriter) inlineContent(nodes []*m.Node) (s string, leading, trailing bool) {
+	inner := markdownWriter{cell: w.cell}
+	inner.Inline = true
+	inner.nodes(nodes)
+	s = inner.B.String()
+	return strings.TrimLeft(s, " "), strings.HasPrefix(s, " "), inner.Space
+}
+
+// wrap writes the inline content of nodes between the delimiters open and
+// close. Whitespace is moved outside of the delimiters.
+func (w *markdownWriter) wrap(open string, nodes []*m.Node, close string) {
+	s, leading, trailing := w.inlineContent(nodes)
+	if leading {
+		w.Space = true
+	}
+	if s == "" {
+		w.Space = w.Space || trailing
+		return
+	}
+	w.Write(open + s + close)
+	if trailing {
+		w.Space = true
+	}
+}
+
+func (w *markdownWriter) element(n *m.Node) {
+	tagName := strings.ToLower(n.TagName)
+	if n.Namespace != m.HTMLNamespace {
+		// Foreign elements (e.g. svg) have no Markdown representation.
+		w.nodes(n.Children)
+		return
+	}
+	if lines.Skipped[tagName] {
+		return
+	}
+	// List items and table rows are written by their list or table.
+	if breaks := lines.BlockBreaks(tagName, w.lists > 0); breaks > 0 && tagName != "li" && tagName != "tr" {
+		if tagName != "ul" && tagName != "ol" {
+			// Markdown requires blank lines between paragraphs.
+			breaks = 2
+		}
+		w.Block(breaks)
+		defer w.Block(breaks)
+	}
+
+	switch tagName {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		s, _, _ := w.inlineContent(n.Children)
+		if strings.HasSuffix(s, "#") {
+			// A trailing sequence of # would close the heading.
+			s = s[:len(s)-1] + `\#`
+		}
+		level := int(tagName[1] - '0')
+		if w.Inline {
+			w.Write(s)
+		} else {
+			w.Write(strings.Repeat("#", level) + " " + s)
+		}
+	case "hr":
+		if !w.Inline {
+			// Unlike ---, this is not a list item when it follows a marker.
+			w.Write("***")
+		}
+	case "br":
+		if w.Inline {
+			w.Space = true
+		} else if w.B.Len() > 0 {
+			w.LineBreak = true
+		}
+	case "a":
+		w.link(n)
+	case "img":
+		alt, _ := n.Attr("alt")
+		src, _ := n.Attr("src")
+		title, _ := n.Attr("title")
+		w.Write("![" + escapeMarkdown(strings.Join(strings.Fields(alt), " ")) + "](" + destination(src) + linkTitle(title) + ")")
+	case "code", "kbd", "samp", "tt":
+		w.code(n)
+	case "pre":
+		w.codeBlock(n)
+	case "ul", "ol":
+		w.list(n, tagName == "ol")
+	case "blockquote":
+		if w.Inline {
+			w.nodes(n.Children)
+			break
+		}
+		prefix := w.Prefix
+		w.Prefix += "> "
+		w.nodes(n.Children)
+		w.Prefix = prefix
+	case "table":
+		w.table(n)
+	default:
+		if delimiter, ok := markdownEmphasis[tagName]; ok {
+			w.wrap(delimiter, n.Children, delimiter)
+			break
+		}
+		w.nodes(n.Children)
+	}
+}
+
+func (w *markdownWriter) link(n *m.Node) {
+	href, ok := n.Attr("href")
+	if !ok {
+		w.nodes(n.Children)
+		return
+	}
+	title, _ := n.Attr("title")
+	text := n.Text()
+	if text == href && title == "" && autolink.MatchString("<"+href+">") {
+		w.Write("<" + href + ">")
+		return
+	}
+	s, leading, trailing := w.inlineContent(n.Children)
+	if leading {
+		w.Space = true
+	}
+	if w.Breaks == 0 && !w.Space && !w.LineBreak && bytes.HasSuffix(w.B.Bytes(), []byte("!")) {
+		// The link would otherwise be an image.
+		w.B.Truncate(w.B.Len() - 1)
+		w.B.WriteString(`\!`)
+	}
+	w.Write("[" + s + "](" + destination(href) + linkTitle(title) + ")")
+	if trailing {
+		w.Space = true
+	}
+}
+
+// destination returns a link destination for url.
+func destination(url string) string {
+	if url == "" || strings.ContainsAny(url, " ()<>\\\n") {
+		r := strings.NewReplacer(`\`, `\\`, "<", `\<`, ">", `\>`, "\n", "%0A")
+		return "<" + r.Replace(url) + ">"
+	}
+	return url
+}
+
+// linkTitle returns the title part of a link, including its leading space.
+func linkTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return ` "` + r.Replace(title) + `"`
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, n := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			n++
+			if n > longest {
+				longest = n
+			}
+		} else {
+			n = 0
+		}
+	}
+	return longest
+}
+
+func (w *markdownWriter) code(n *m.Node) {
+	s := strings.Join(strings.Fields(n.Text()), " ")
+	if s == "" {
+		return
+	}
+	if w.cell {
+		s = strings.ReplaceAll(s, "|", `\|`)
+	}
+	ticks := strings.Repeat("`", longestRun(s, '`')+1)
+	if s[0] == '`' || s[len(s)-1] == '`' {
+		s = " " + s + " "
+	}
+	w.Write(ticks + s + ticks)
+}
+
+func (w *markdownWriter) codeBlock(n *m.Node) {
+	var language string
+	content := n.Text()
+	if len(n.Children) == 1 && n.Children[0].Type == m.ElementNode && strings.EqualFold(n.Children[0].TagName, "code") {
+		class, _ := n.Children[0].Attr("class")
+		for _, c := range strings.Fields(class) {
+			if strings.HasPrefix(c, "language-") {
+				language = strings.TrimPrefix(c, "language-")
+				break
+			}
+		}
+	}
+	if w.Inline {
+		w.code(n)
+		return
+	}
+
+	// A leading newline of a pre element is ignored by HTML.
+	content = strings.TrimPrefix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	fence := "```"
+	if n := longestRun(content, '`'); n >= len(fence) {
+		fence = strings.Repeat("`", n+1)
+	}
+
+	w.Flush()
+	w.B.WriteString(fence + language)
+	if content != "" {
+		for _, line := range strings.Split(content, "\n") {
+			w.B.WriteByte('\n')
+			if line == "" {
+				w.B.WriteString(strings.TrimRight(w.Prefix, " "))
+			} else {
+				w.B.WriteString(w.Prefix + line)
+			}
+		}
+	}
+	w.B.WriteByte('\n')
+	w.B.WriteString(w.Prefix + fence)
+}
+
+func (w *markdownWriter) list(n *m.Node, ordered bool) {
+	if w.Inline {
+		w.nodes(n.Children)
+		return
+	}
+
+	number := 1
+	if start, ok := n.Attr("start"); ok && ordered {
+		if i, err := strconv.Atoi(start); err == nil && i >= 0 {
+			number = i
+		}
+	}
+
+	w.lists++
+	defer func() {
+		w.lists--
+	}()
+
+	prefix := w.Prefix
+	for _, child := range n.Children {
+		if child.Type != m.ElementNode || !strings.EqualFold(child.TagName, "li") {
+			w.nodes([]*m.Node{child})
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		w.Block(1)
+		if w.Marker != "" {
+			// The list starts an item, so both markers are on the same line.
+			w.Marker += marker
+		} else {
+			w.Marker = prefix + marker
+		}
+		w.Prefix = prefix + strings.Repeat(" ", len(marker))
+		w.nodes(child.Children)
+		if w.Marker != "" {
+			// The item is empty.
+			w.Flush()
+		}
+		w.Prefix = prefix
+		w.Marker = ""
+		w.Block(1)
+	}
+}
+
+func (w *markdownWriter) table(n *m.Node) {
+	if w.Inline {
+		w.nodes(n.Children)
+		return
+	}
+
+	var rows [][]string
+	var aligns []Align
+	var walk func(nodes []*m.Node)
+	walk = func(nodes []*m.Node) {
+		for _, n := range nodes {
+			if n.Type != m.ElementNode {
+				continue
+			}
+			switch strings.ToLower(n.TagName) {
+			case "tr":
+				var row []string
+				for _, cell := range n.Children {
+					if cell.Type != m.ElementNode {
+						continue
+					}
+					tagName := strings.ToLower(cell.TagName)
+					if tagName != "td" && tagName != "th" {
+						continue
+					}
+					if len(rows) == 0 {
+						aligns = append(aligns, cellAlign(cell))
+					}
+					w.cell = true
+					s, _, _ := w.inlineContent(cell.Children)
+					w.cell = false
+					row = append(row, s)
+				}
+				rows = append(rows, row)
+			case "caption":
+				s, _, _ := w.inlineContent(n.Children)
+				w.Write(s)
+				w.Block(2)
+			default:
+				walk(n.Children)
+			}
+		}
+	}
+	walk(n.Children)
+	if len(rows) == 0 {
+		return
+	}
+
+	widths := make([]int, len(aligns))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 3)
+				aligns = append(aligns, AlignNone)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	writeRow := func(cells []string) {
+		var b strings.Builder
+		b.WriteByte('|')
+		for i, width := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteByte(' ')
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(cell)))
+			b.WriteString(" |")
+		}
+		w.Block(1)
+		w.Write(b.String())
+	}
+	writeRow(rows[0])
+	delimiters := make([]string, len(widths))
+	for i, width := range widths {
+		d := []byte(strings.Repeat("-", width))
+		switch aligns[i] {
+		case AlignLeft:
+			d[0] = ':'
+		case AlignCenter:
+			d[0], d[width-1] = ':', ':'
+		case AlignRight:
+			d[width-1] = ':'
+		}
+		delimiters[i] = string(d)
+	}
+	writeRow(delimiters)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+}
+
+func cellAlign(n *m.Node) Align {
+	align, _ := n.Attr("align")
+	if style, ok := n.Attr("style"); ok && align == "" {
+		for _, declaration := range strings.Split(style, ";") {
+			if kv := strings.SplitN(declaration, ":", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) == "text-align" {
+				align = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	for i, name := range alignNames {
+		if name != "" && strings.EqualFold(align, name) {
+			return Align(i)
+		}
+	}
+	return AlignNone
+}
@@ -0,0 +1,59 @@
+package mtest
+
+import (
+	"strings"
+)
+
+// diff returns a line diff of a and b. Lines only in a are prefixed with
+// "-", lines only in b with "+", and common lines with a space.
+func diff(a, b string) string {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	line := func(prefix byte, s string) {
+		out.WriteByte(prefix)
+		out.WriteString(s)
+		out.WriteByte('\n')
+	}
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			line(' ', x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			line('-', x[i])
+			i++
+		default:
+			line('+', y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		line('-', x[i])
+	}
+	for ; j < len(y); j++ {
+		line('+', y[j])
+	}
+	return out.String()
+}
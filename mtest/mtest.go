@@ -0,0 +1,97 @@
+// Package mtest provides helpers for testing elements built with package m.
+//
+// Rather than comparing rendered HTML byte for byte, the helpers assert the
+// structure of the expanded element tree.
+package mtest
+
+import (
+	"layeh.com/m"
+)
+
+// AssertHasElement asserts that the element tree contains a node matching
+// selector, and returns the first such node. nil is returned if the
+// assertion failed.
+//
+// See m.Find for the selector syntax.
+func AssertHasElement(t TB, element m.Element, selector string) *m.Node {
+	t.Helper()
+	n, err := m.Find(element, selector)
+	if err != nil {
+		t.Errorf("mtest: %s", err)
+		return nil
+	}
+	if n == nil {
+		t.Errorf("no element matches %q in:\n%s", selector, pretty(element))
+		return nil
+	}
+	return n
+}
+
+// AssertText asserts that the text of the first node matching selector is
+// expected. Whitespace is normalized before comparing.
+func AssertText(t TB, element m.Element, selector, expected string) bool {
+	t.Helper()
+	n := AssertHasElement(t, element, selector)
+	if n == nil {
+		return false
+	}
+	if text := normalizeSpace(n.Text()); text != normalizeSpace(expected) {
+		t.Errorf("text of %q\ngot:\n%q\nexpected:\n%q", selector, text, normalizeSpace(expected))
+		return false
+	}
+	return true
+}
+
+// AssertAttr asserts that the first node matching selector has the
+// attribute key with the value expected.
+func AssertAttr(t TB, element m.Element, selector, key, expected string) bool {
+	t.Helper()
+	n := AssertHasElement(t, element, selector)
+	if n == nil {
+		return false
+	}
+	value, ok := n.Attr(key)
+	if !ok {
+		t.Errorf("attribute %q of %q is not set; expected %q", key, selector, expected)
+		return false
+	}
+	if value != expected {
+		t.Errorf("attribute %q of %q\ngot:\n%q\nexpected:\n%q", key, selector, value, expected)
+		return false
+	}
+	return true
+}
+
+// AssertEqualHTML asserts that expected and actual render equivalent HTML.
+//
+// Before comparing, attributes are sorted by key, and whitespace in text is
+// normalized as browsers render it: runs of whitespace are collapsed to a
+// single space, which is removed at the start and end of block elements
+// (e.g. p) and between them. Whitespace is significant in pre, textarea,
+// script and style elements, and raw HTML is compared verbatim. On failure,
+// a line diff of the pretty-printed trees is reported.
+func AssertEqualHTML(t TB, expected, actual m.Element) bool {
+	t.Helper()
+	expectedNodes, err := m.Nodes(expected)
+	if err != nil {
+		t.Errorf("mtest: expected: %s", err)
+		return false
+	}
+	actualNodes, err := m.Nodes(actual)
+	if err != nil {
+		t.Errorf("mtest: actual: %s", err)
+		return false
+	}
+	e, a := prettyNodes(normalize(expectedNodes)), prettyNodes(normalize(actualNodes))
+	if e != a {
+		t.Errorf("HTML differs (-expected +actual):\n%s", diff(e, a))
+		return false
+	}
+	return true
+}
+
+// TB is the subset of testing.TB used by the package.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
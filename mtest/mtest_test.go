@@ -0,0 +1,194 @@
+package mtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "layeh.com/m"
+)
+
+type fakeT struct {
+	Errors []string
+}
+
+func (*fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.Errors = append(t.Errors, fmt.Sprintf(format, args...))
+}
+
+var page = M("div#app",
+	M("ul.nav",
+		M("li", M("a[href=/]", T("Home"))),
+		M("li.active", M("a[href=/about]", T("  About\n  us "))),
+	),
+	M("input[type=checkbox][checked]"),
+)
+
+func TestAsserts(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Assert func(t TB) bool
+		Pass   bool
+	}{
+		{"HasElement", func(t TB) bool { return AssertHasElement(t, page, "ul > li.active a") != nil }, true},
+		{"HasElement missing", func(t TB) bool { return AssertHasElement(t, page, "table") != nil }, false},
+		{"HasElement invalid", func(t TB) bool { return AssertHasElement(t, page, "a[") != nil }, false},
+		{"Text", func(t TB) bool { return AssertText(t, page, "li.active", "About us") }, true},
+		{"Text mismatch", func(t TB) bool { return AssertText(t, page, "li", "About us") }, false},
+		{"Attr", func(t TB) bool { return AssertAttr(t, page, "li.active a", "href", "/about") }, true},
+		{"Attr empty", func(t TB) bool { return AssertAttr(t, page, "input", "checked", "") }, true},
+		{"Attr mismatch", func(t TB) bool { return AssertAttr(t, page, "a", "href", "/about") }, false},
+		{"Attr missing", func(t TB) bool { return AssertAttr(t, page, "a", "title", "") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var ft fakeT
+			if pass := tt.Assert(&ft); pass != tt.Pass {
+				t.Fatalf("got %v; expected %v (errors: %q)", pass, tt.Pass, ft.Errors)
+			}
+			if failed := len(ft.Errors) > 0; failed == tt.Pass {
+				t.Fatalf("got errors %q", ft.Errors)
+			}
+		})
+	}
+}
+
+func TestAssertEqualHTML(t *testing.T) {
+	var ft fakeT
+	equal := AssertEqualHTML(&ft,
+		M("p.a[title=x][data-id=1]", T(" Hello   World "), M("br")),
+		M("p", Attr("data-id", "1"), Attr("title", "x"), Attr("class", "a"), T("Hello World"), M("br")),
+	)
+	if !equal || len(ft.Errors) > 0 {
+		t.Fatalf("expected equal HTML; got errors %q", ft.Errors)
+	}
+
+	equal = AssertEqualHTML(&ft,
+		M("ul", M("li", T("A")), M("li", T("B"))),
+		M("ul", M("li", T("A")), M("li.b", T("B"))),
+	)
+	if equal || len(ft.Errors) != 1 {
+		t.Fatalf("expected one error; got %q", ft.Errors)
+	}
+	expected := `HTML differs (-expected +actual):
+ <ul>
+   <li>A</li>
+-  <li>B</li>
++  <li class="b">B</li>
+ </ul>
+`
+	if ft.Errors[0] != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", ft.Errors[0], expected)
+	}
+}
+
+func TestAssertEqualHTML_whitespace(t *testing.T) {
+	tests := []struct {
+		Expected, Actual Element
+		Equal            bool
+	}{
+		{M("p", T("a  b")), M("p", T("a b")), true},
+		{M("div", T("\n  "), M("p", T(" a ")), T("\n"), M("p", T("b"))), M("div", M("p", T("a")), M("p", T("b"))), true},
+		{M("p", M("b", T("a")), T(" \n "), M("b", T("b"))), M("p", M("b", T("a")), T(" "), M("b", T("b"))), true},
+		{M("p", M("b", T("a")), T(" "), M("b", T("b"))), M("p", M("b", T("a")), M("b", T("b"))), false},
+		{M("p", T("a "), M("b", T("b"))), M("p", T("a"), M("b", T("b"))), false},
+		{M("pre", T("a  b")), M("pre", T("a b")), false},
+		{M("pre", M("code", T("a\n  b"))), M("pre", M("code", T("a\n b"))), false},
+		{M("textarea", T(" a")), M("textarea", T("a")), false},
+		{M("p", Raw("a  b")), M("p", Raw("a b")), false},
+	}
+	for _, tt := range tests {
+		var ft fakeT
+		if got := AssertEqualHTML(&ft, tt.Expected, tt.Actual); got != tt.Equal {
+			t.Errorf("%s, %s: got %t; expected %t", RenderString(tt.Expected), RenderString(tt.Actual), got, tt.Equal)
+		}
+	}
+}
+
+func Test_diff(t *testing.T) {
+	tests := []struct {
+		A, B     string
+		Expected []string
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", []string{" a", " b", " c"}},
+		{"a\nb\nc\n", "a\nc\n", []string{" a", "-b", " c"}},
+		{"a\n", "b\n", []string{"-a", "+b"}},
+		{"a\nc\n", "a\nb\nc\nd\n", []string{" a", "+b", " c", "+d"}},
+	}
+
+	for _, tt := range tests {
+		got := diff(tt.A, tt.B)
+		if expected := strings.Join(tt.Expected, "\n") + "\n"; got != expected {
+			t.Errorf("diff(%q, %q)\ngot:\n%s\nexpected:\n%s", tt.A, tt.B, got, expected)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "testdata", "card.golden")
+	card := M("div.card", M("h5.card-title", T("Title")), M("p", T("Body")))
+
+	var ft fakeT
+	if snapshot(&ft, file, card, false) || len(ft.Errors) != 1 {
+		t.Fatalf("expected missing golden file error; got %q", ft.Errors)
+	}
+	if !strings.Contains(ft.Errors[0], "-mtest.update") {
+		t.Fatalf("expected error to mention update flag; got %q", ft.Errors[0])
+	}
+
+	ft = fakeT{}
+	if !snapshot(&ft, file, card, true) || len(ft.Errors) > 0 {
+		t.Fatalf("expected update to succeed; got %q", ft.Errors)
+	}
+	golden, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<div class="card">
+  <h5 class="card-title">Title</h5>
+  <p>Body</p>
+</div>
+`
+	if string(golden) != expected {
+		t.Fatalf("golden file\ngot:\n%s\nexpected:\n%s", golden, expected)
+	}
+
+	if !snapshot(&ft, file, card, false) || len(ft.Errors) > 0 {
+		t.Fatalf("expected snapshot to match; got %q", ft.Errors)
+	}
+
+	changed := M("div.card", M("h5.card-title", T("Title")), M("p.lead", T("Body")))
+	if snapshot(&ft, file, changed, false) || len(ft.Errors) != 1 {
+		t.Fatalf("expected one error; got %q", ft.Errors)
+	}
+	if !strings.Contains(ft.Errors[0], "+  <p class=\"lead\">Body</p>") {
+		t.Fatalf("expected diff in error; got %q", ft.Errors[0])
+	}
+}
+
+func Test_goldenName(t *testing.T) {
+	tests := []struct {
+		Name, Expected string
+	}{
+		{"TestCard", "TestCard"},
+		{"TestCard/primary button", "TestCard/primary_button"},
+		{"TestCard/a:b*c", "TestCard/a_b_c"},
+		{"TestCard/../../x", "TestCard/__/__/x"},
+		{"../TestCard/./a..b", "__/TestCard/_/a..b"},
+	}
+	for _, tt := range tests {
+		if got := goldenName(tt.Name); got != tt.Expected {
+			t.Errorf("goldenName(%q) = %q; expected %q", tt.Name, got, tt.Expected)
+		}
+	}
+}
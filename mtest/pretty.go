@@ -0,0 +1,203 @@
+package mtest
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"layeh.com/m"
+	"layeh.com/m/internal/lines"
+)
+
+// pretty returns the pretty-printed HTML of element, for use in failure
+// messages.
+func pretty(element m.Element) string {
+	nodes, err := m.Nodes(element)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return prettyNodes(nodes)
+}
+
+// prettyNodes returns nodes as indented HTML, one node per line. Elements
+// whose only child is text are written on a single line.
+func prettyNodes(nodes []*m.Node) string {
+	var b strings.Builder
+	writeNodes(&b, nodes, 0)
+	return b.String()
+}
+
+func writeNodes(b *strings.Builder, nodes []*m.Node, depth int) {
+	for _, n := range nodes {
+		b.WriteString(strings.Repeat("  ", depth))
+		switch n.Type {
+		case m.TextNode:
+			b.WriteString(html.EscapeString(n.Data))
+		case m.RawNode:
+			b.WriteString(n.Data)
+		case m.ElementNode:
+			// Foreign elements without children are self-closed, as they
+			// are when rendered.
+			selfClosing := n.Namespace != m.HTMLNamespace && len(n.Children) == 0
+			writeStartTag(b, n, selfClosing)
+			if n.Void() || selfClosing {
+				break
+			}
+			if len(n.Children) == 1 && n.Children[0].Type == m.TextNode {
+				b.WriteString(html.EscapeString(n.Children[0].Data))
+			} else if len(n.Children) > 0 {
+				b.WriteByte('\n')
+				writeNodes(b, n.Children, depth+1)
+				b.WriteString(strings.Repeat("  ", depth))
+			}
+			b.WriteString("</")
+			b.WriteString(n.TagName)
+			b.WriteByte('>')
+		}
+		b.WriteByte('\n')
+	}
+}
+
+func writeStartTag(b *strings.Builder, n *m.Node, selfClosing bool) {
+	b.WriteByte('<')
+	b.WriteString(n.TagName)
+	for _, attr := range n.Attributes {
+		b.WriteByte(' ')
+		b.WriteString(attr.Key)
+		b.WriteString(`="`)
+		b.WriteString(template.HTMLEscapeString(attr.Value))
+		b.WriteByte('"')
+	}
+	if selfClosing {
+		b.WriteByte('/')
+	}
+	b.WriteByte('>')
+}
+
+// normalize returns a copy of nodes with sorted attributes and whitespace
+// normalized as it is rendered by browsers.
+//
+// Outside of preformatted elements, runs of whitespace in text are collapsed
+// to a single space, which is removed at the start and end of block elements
+// and next to them. Text in preformatted elements and raw nodes is kept
+// verbatim.
+func normalize(nodes []*m.Node) []*m.Node {
+	return normalizeChildren(nodes, true, false)
+}
+
+// normalizeChildren normalizes the children of an element, which is a block
+// element if block is true and a preformatted element if pre is true.
+func normalizeChildren(nodes []*m.Node, block, pre bool) []*m.Node {
+	var normalized []*m.Node
+	for i, n := range nodes {
+		switch n.Type {
+		case m.TextNode:
+			data := n.Data
+			if !pre {
+				data = collapseSpace(data)
+				if (i == 0 && block) || (i > 0 && isBlock(nodes[i-1])) {
+					data = strings.TrimPrefix(data, " ")
+				}
+				if (i == len(nodes)-1 && block) || (i < len(nodes)-1 && isBlock(nodes[i+1])) {
+					data = strings.TrimSuffix(data, " ")
+				}
+			}
+			if data == "" {
+				continue
+			}
+			normalized = append(normalized, &m.Node{
+				Type: m.TextNode,
+				Data: data,
+			})
+		case m.RawNode:
+			normalized = append(normalized, &m.Node{
+				Type: m.RawNode,
+				Data: n.Data,
+			})
+		case m.ElementNode:
+			attributes := append([]m.Attribute(nil), n.Attributes...)
+			sort.SliceStable(attributes, func(i, j int) bool {
+				return attributes[i].Key < attributes[j].Key
+			})
+			normalized = append(normalized, &m.Node{
+				Type:       m.ElementNode,
+				Namespace:  n.Namespace,
+				TagName:    n.TagName,
+				Attributes: attributes,
+				Children:   normalizeChildren(n.Children, isBlock(n), pre || isPreformatted(n)),
+			})
+		}
+	}
+	return normalized
+}
+
+// blockElements are the HTML elements around which whitespace is not
+// rendered, in addition to those of lines.Blocks.
+var blockElements = map[string]bool{
+	"base":     true,
+	"body":     true,
+	"br":       true,
+	"caption":  true,
+	"col":      true,
+	"colgroup": true,
+	"head":     true,
+	"html":     true,
+	"link":     true,
+	"meta":     true,
+	"script":   true,
+	"style":    true,
+	"tbody":    true,
+	"td":       true,
+	"tfoot":    true,
+	"th":       true,
+	"thead":    true,
+	"title":    true,
+}
+
+func isBlock(n *m.Node) bool {
+	if n.Type != m.ElementNode || n.Namespace != m.HTMLNamespace {
+		return false
+	}
+	tagName := strings.ToLower(n.TagName)
+	return lines.Blocks[tagName] > 0 || blockElements[tagName]
+}
+
+// isPreformatted reports whether the whitespace of the text of n is
+// significant.
+func isPreformatted(n *m.Node) bool {
+	if n.Namespace != m.HTMLNamespace {
+		return false
+	}
+	switch strings.ToLower(n.TagName) {
+	case "pre", "textarea", "script", "style":
+		return true
+	}
+	return false
+}
+
+// collapseSpace replaces each run of whitespace in s with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
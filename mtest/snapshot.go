@@ -0,0 +1,82 @@
+package mtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"layeh.com/m"
+)
+
+var update = flag.Bool("mtest.update", false, "rewrite mtest golden files with the current output")
+
+// Snapshot asserts that the pretty-printed HTML of element matches the
+// golden file testdata/name.golden, relative to the package directory.
+//
+// When the test binary is run with the -mtest.update flag, the golden file
+// is written instead:
+//
+//	go test . -mtest.update
+//
+// name is typically t.Name(). Characters that are not letters, digits, '-',
+// '_', '.' or '/' are replaced with underscores, as are the dots of "." and
+// ".." path elements, so that the file is always in testdata.
+func Snapshot(t TB, name string, element m.Element) bool {
+	t.Helper()
+	file := filepath.Join("testdata", filepath.FromSlash(goldenName(name))+".golden")
+	return snapshot(t, file, element, *update)
+}
+
+func snapshot(t TB, file string, element m.Element, update bool) bool {
+	t.Helper()
+	nodes, err := m.Nodes(element)
+	if err != nil {
+		t.Errorf("mtest: %s", err)
+		return false
+	}
+	actual := prettyNodes(nodes)
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Errorf("mtest: %s", err)
+			return false
+		}
+		if err := os.WriteFile(file, []byte(actual), 0644); err != nil {
+			t.Errorf("mtest: %s", err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		t.Errorf("mtest: %s (run with -mtest.update to create it)", err)
+		return false
+	}
+	if !bytes.Equal(expected, []byte(actual)) {
+		t.Errorf("%s differs (-golden +actual):\n%s", file, diff(string(expected), actual))
+		return false
+	}
+	return true
+}
+
+func goldenName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		case r == '-', r == '_', r == '.', r == '/':
+			return r
+		}
+		return '_'
+	}, name)
+	elements := strings.Split(name, "/")
+	for i, element := range elements {
+		if element == "." || element == ".." {
+			elements[i] = strings.Repeat("_", len(element))
+		}
+	}
+	return strings.Join(elements, "/")
+}
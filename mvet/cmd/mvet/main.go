@@ -0,0 +1,15 @@
+// Command mvet checks calls to M and related functions, as described by
+// package layeh.com/m/mvet. It is run by go vet:
+//
+//	go vet -vettool=$(which mvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"layeh.com/m/mvet"
+)
+
+func main() {
+	unitchecker.Main(mvet.Analyzer)
+}
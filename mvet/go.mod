@@ -0,0 +1,18 @@
+// mvet only uses the exported API of layeh.com/m, which it requires at a
+// tagged version. To build it against a checkout of both modules, use a
+// workspace, which is not committed:
+//
+//	go work init . ./mvet
+module layeh.com/m/mvet
+
+go 1.23.0
+
+require (
+	golang.org/x/tools v0.30.0
+	layeh.com/m v0.1.0
+)
+
+require (
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -0,0 +1,268 @@
+// Package mvet defines an analyzer that checks calls to M and related
+// functions, which otherwise fail or behave unexpectedly at run time.
+//
+// The analyzer reports:
+//
+//   - constant selectors that are invalid, which cause M to panic;
+//   - selectors that are built by concatenation or fmt.Sprintf, whose dynamic
+//     values should be given with Attr instead;
+//   - attributes that follow children, which are not added to the element;
+//   - children of void elements (e.g. img), which are not rendered.
+//
+// The analyzer can be run with go vet using the cmd/mvet command. mvet is a
+// separate module, so that layeh.com/m does not depend on golang.org/x/tools,
+// and it only uses the exported API of layeh.com/m:
+//
+//	go install layeh.com/m/mvet/cmd/mvet@latest
+//	go vet -vettool=$(which mvet) ./...
+package mvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"layeh.com/m"
+)
+
+// Analyzer checks calls to M, NS, SVG and MathML.
+var Analyzer = &analysis.Analyzer{
+	Name:     "mvet",
+	Doc:      "check selectors and the order of attributes in calls to m.M",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+const mPath = "layeh.com/m"
+
+// selectorFuncs are the functions of m that take a selector, and the index
+// of the selector argument.
+var selectorFuncs = map[string]int{
+	"M":      0,
+	"NS":     1,
+	"SVG":    0,
+	"MathML": 0,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		name := funcName(pass, call.Fun)
+		index, ok := selectorFuncs[name]
+		if !ok || len(call.Args) <= index {
+			return
+		}
+		sel := call.Args[index]
+
+		// voidTag is the tag name of a void element, whose children are
+		// not rendered.
+		var voidTag string
+		if tv := pass.TypesInfo.Types[sel]; tv.Value != nil && tv.Value.Kind() == constant.String {
+			s := constant.StringVal(tv.Value)
+			el, err := m.NewElement(s)
+			if err != nil {
+				pass.Reportf(sel.Pos(), "invalid selector %q: %v", s, unwrap(err))
+				return
+			}
+			if name == "M" {
+				if nodes, _ := m.Nodes(el); nodes[0].Void() {
+					voidTag = nodes[0].TagName
+				}
+			} else if s == "" || strings.IndexByte("#.[", s[0]) >= 0 {
+				pass.Reportf(sel.Pos(), "invalid selector %q: missing tag name", s)
+				return
+			}
+		} else if isDynamic(pass, sel) {
+			pass.Reportf(sel.Pos(), "selector is built from dynamic values; use m.Attr for them instead")
+		}
+
+		if call.Ellipsis.IsValid() {
+			return
+		}
+		elements := call.Args[index+1:]
+		var children ast.Expr
+		for _, el := range elements {
+			switch kind(pass, el) {
+			case attributeKind:
+				if children != nil {
+					pass.Reportf(el.Pos(), "attribute after children is ignored; attributes must be the first elements")
+				}
+			case childKind:
+				if children == nil {
+					children = el
+				}
+			}
+		}
+		if children != nil && voidTag != "" {
+			pass.Reportf(children.Pos(), "children of void element %s are not rendered", voidTag)
+		}
+	})
+	return nil, nil
+}
+
+// funcName returns the name of the function of package m that fun refers
+// to, or "" if it does not refer to one.
+func funcName(pass *analysis.Pass, fun ast.Expr) string {
+	var id *ast.Ident
+	switch fun := astutil.Unparen(fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return ""
+	}
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != mPath {
+		return ""
+	}
+	if sig := fn.Type().(*types.Signature); sig.Recv() != nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// isDynamic reports whether the non-constant expression e is built by
+// concatenation or fmt.Sprintf.
+func isDynamic(pass *analysis.Pass, e ast.Expr) bool {
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD
+	case *ast.CallExpr:
+		sel, ok := astutil.Unparen(e.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+		return ok && fn.Pkg() != nil && fn.Pkg().Path() == "fmt" && fn.Name() == "Sprintf"
+	}
+	return false
+}
+
+type elementKind int
+
+const (
+	nilKind elementKind = iota
+	attributeKind
+	childKind
+)
+
+// kind returns whether e is an attribute or a child. Calls to If, IfElse and
+// the functions that take function literals, such as IfFunc and Switch, are
+// attributes if they only return attributes or nil.
+func kind(pass *analysis.Pass, e ast.Expr) elementKind {
+	e = astutil.Unparen(e)
+	tv := pass.TypesInfo.Types[e]
+	if tv.IsNil() {
+		return nilKind
+	}
+	if isAttribute(tv.Type) {
+		return attributeKind
+	}
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return childKind
+	}
+	var results []ast.Expr
+	switch name := funcName(pass, call.Fun); name {
+	case "Attr", "Attrf", "Classes", "Class", "Style":
+		return attributeKind
+	case "If":
+		if len(call.Args) == 2 {
+			results = call.Args[1:]
+		}
+	case "IfElse":
+		if len(call.Args) == 3 {
+			results = call.Args[1:]
+		}
+	case "IfFunc", "IfElseFunc":
+		if len(call.Args) > 1 {
+			results = funcResults(call.Args[1:])
+		}
+	case "Switch", "Cond":
+		branches := call.Args
+		if name == "Switch" && len(branches) > 0 {
+			branches = branches[1:]
+		}
+		var fns []ast.Expr
+		for _, b := range branches {
+			b, ok := astutil.Unparen(b).(*ast.CallExpr)
+			if !ok || len(b.Args) == 0 {
+				return childKind
+			}
+			switch funcName(pass, b.Fun) {
+			case "Case", "When", "Default":
+				fns = append(fns, b.Args[len(b.Args)-1])
+			default:
+				return childKind
+			}
+		}
+		results = funcResults(fns)
+	}
+	if len(results) == 0 {
+		return childKind
+	}
+	k := nilKind
+	for _, result := range results {
+		switch kind(pass, result) {
+		case attributeKind:
+			k = attributeKind
+		case childKind:
+			return childKind
+		}
+	}
+	return k
+}
+
+// funcResults returns the results of the return statements of the function
+// literals fns, or nil if any of fns is not a function literal.
+func funcResults(fns []ast.Expr) []ast.Expr {
+	var results []ast.Expr
+	for _, fn := range fns {
+		lit, ok := astutil.Unparen(fn).(*ast.FuncLit)
+		if !ok {
+			return nil
+		}
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				results = append(results, n.Results...)
+			}
+			return true
+		})
+	}
+	return results
+}
+
+// isAttribute reports whether t is *m.Attribute.
+func isAttribute(t types.Type) bool {
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == mPath && obj.Name() == "Attribute"
+}
+
+// unwrap returns the underlying error of a selector error, as the selector
+// is already included in the diagnostic.
+func unwrap(err error) error {
+	if err, ok := err.(*m.SelectorError); ok {
+		return err.Err
+	}
+	return err
+}
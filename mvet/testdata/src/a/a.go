@@ -0,0 +1,43 @@
+package a
+
+import (
+	"fmt"
+
+	"layeh.com/m"
+)
+
+const sel = "div.a"
+
+func f(id string, cond bool, child m.Element) {
+	m.M("div#a.b[c=d]", m.Attr("e", "f"), m.T("g"))
+	m.M(sel, child)
+	m.M("div#", nil)   // want `invalid selector "div#": invalid ID`
+	m.M("a[href", nil) // want `invalid selector "a\[href": invalid attribute`
+	m.NS("", ".a")     // want `invalid selector ".a": missing tag name`
+	m.SVG("path")
+	m.M(id)
+	m.M("div#" + id)               // want `selector is built from dynamic values`
+	m.M(fmt.Sprintf("div#%s", id)) // want `selector is built from dynamic values`
+
+	m.M("div", nil, m.If(cond, m.Attr("a", "b")), &m.Attribute{Key: "c"}, m.T("d"))
+	m.M("div", m.T("a"), m.Attr("b", "c"))                   // want `attribute after children is ignored`
+	m.M("div", child, m.IfElse(cond, m.Attr("a", "b"), nil)) // want `attribute after children is ignored`
+	m.M("div", m.If(cond, m.T("a")), &m.Attribute{})         // want `attribute after children is ignored`
+	m.M("div", m.Class("a", cond), m.Classes(nil), m.Style(nil), m.T("b"))
+	m.M("div", m.T("a"), m.Class("b", cond)) // want `attribute after children is ignored`
+
+	attr := func() m.Element { return m.Attr("a", "b") }
+	m.M("div", m.IfFunc(cond, func() m.Element { return m.Attr("a", "b") }), m.Attr("c", "d"), m.T("e"))
+	m.M("div", m.Cond(m.When(cond, func() m.Element { return nil }), m.Default(func() m.Element { return m.Class("a", cond) })), m.Attr("b", "c"))
+	m.M("div", m.Switch(id, m.Case("a", func() m.Element { return m.Attr("a", "b") })), m.Attr("c", "d"))
+	m.M("div", m.IfFunc(cond, attr), m.Attr("a", "b"))                                                                                   // want `attribute after children is ignored`
+	m.M("div", m.IfElseFunc(cond, func() m.Element { return m.Attr("a", "b") }, func() m.Element { return m.T("c") }), m.Attr("d", "e")) // want `attribute after children is ignored`
+	m.M("div", m.Cond(m.Default(func() m.Element { return m.T("a") })), m.Attr("b", "c"))                                                // want `attribute after children is ignored`
+
+	m.M("img[src=a]", m.Attr("alt", "b"))
+	m.M("IMG", m.Attr("alt", "b"), m.T("c")) // want `children of void element img are not rendered`
+	m.M("br", nil)
+	m.NS("", "img", m.T("a"))
+	elements := []m.Element{m.T("a"), m.Attr("b", "c")}
+	m.M("input", elements...)
+}
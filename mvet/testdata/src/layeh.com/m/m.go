@@ -0,0 +1,33 @@
+// Package m is a stub of layeh.com/m.
+package m
+
+type Element interface {
+	Element() Element
+}
+
+type Attribute struct {
+	Key, Value string
+}
+
+func (*Attribute) Element() Element { return nil }
+
+func M(selector string, elements ...Element) Element               { return nil }
+func NS(namespace, selector string, elements ...Element) Element   { return nil }
+func SVG(selector string, elements ...Element) Element             { return nil }
+func Attr(key, value string) Element                               { return nil }
+func T(text string) Element                                        { return nil }
+func If(cond bool, ifTrue Element) Element                         { return nil }
+func IfElse(cond bool, ifTrue, ifFalse Element) Element            { return nil }
+func Classes(classes map[string]bool) Element                      { return nil }
+func Class(name string, cond bool) Element                         { return nil }
+func Style(properties map[string]string) Element                   { return nil }
+func IfFunc(cond bool, ifTrue func() Element) Element              { return nil }
+func IfElseFunc(cond bool, ifTrue, ifFalse func() Element) Element { return nil }
+
+type Branch struct{}
+
+func Case(value any, fn func() Element) Branch     { return Branch{} }
+func When(cond bool, fn func() Element) Branch     { return Branch{} }
+func Default(fn func() Element) Branch             { return Branch{} }
+func Switch(value any, branches ...Branch) Element { return nil }
+func Cond(branches ...Branch) Element              { return nil }
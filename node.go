@@ -0,0 +1,148 @@
+package m
+
+import (
+	"io"
+	"strings"
+)
+
+// NodeType is the type of a Node.
+type NodeType int
+
+// Node types.
+const (
+	ElementNode NodeType = iota + 1
+	TextNode
+	RawNode
+)
+
+// Node is a node of an expanded element tree.
+//
+// Nodes are created by Nodes. A *Node is itself an Element, so a tree that
+// has been inspected or modified can be rendered again.
+type Node struct {
+	Type NodeType
+
+	// Namespace, TagName and Attributes are set for ElementNode. Namespace
+	// is HTMLNamespace for elements created with M.
+	Namespace  string
+	TagName    string
+	Attributes []Attribute
+
+	// Data is the unescaped text of a TextNode, or the HTML of a RawNode.
+	Data string
+
+	Children []*Node
+}
+
+// Nodes expands element into a tree of nodes.
+//
+// Components are replaced by the elements they return, and elements such as
+// S and For are replaced by their contents. Adjacent text is merged into a
+// single TextNode, and empty text is omitted.
+//
+// A non-nil error is returned if the element could not be expanded.
+func Nodes(element Element) ([]*Node, error) {
+	return appendNodes(nil, element)
+}
+
+func appendNodes(nodes []*Node, element Element) ([]*Node, error) {
+	if element == nil {
+		return nodes, nil
+	}
+	if internal, ok := element.(internalElement); ok {
+		return internal.appendNodes(nodes)
+	}
+	return appendNodes(nodes, element.Element())
+}
+
+func appendText(nodes []*Node, text string) []*Node {
+	if text == "" {
+		return nodes
+	}
+	if n := len(nodes); n > 0 && nodes[n-1].Type == TextNode {
+		// Replace rather than modify the previous node, as it may be owned by
+		// the caller.
+		nodes[n-1] = &Node{
+			Type: TextNode,
+			Data: nodes[n-1].Data + text,
+		}
+		return nodes
+	}
+	return append(nodes, &Node{
+		Type: TextNode,
+		Data: text,
+	})
+}
+
+// Attr returns the value of the attribute with the given key, and whether
+// the attribute exists.
+func (n *Node) Attr(key string) (string, bool) {
+	for _, attr := range n.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Text returns the concatenated text of n and its descendants. Raw HTML is
+// not included.
+func (n *Node) Text() string {
+	var b strings.Builder
+	n.writeText(&b)
+	return b.String()
+}
+
+func (n *Node) writeText(b *strings.Builder) {
+	switch n.Type {
+	case TextNode:
+		b.WriteString(n.Data)
+	case ElementNode:
+		for _, child := range n.Children {
+			child.writeText(b)
+		}
+	}
+}
+
+// Void reports whether n is a void element (e.g. br), which cannot have
+// children and is rendered without an end tag.
+func (n *Node) Void() bool {
+	return n.Type == ElementNode && n.Namespace == HTMLNamespace && voidElements[n.TagName]
+}
+
+// Element implements Element.
+func (*Node) Element() Element { return nil }
+
+func (n *Node) render(w *renderer) error {
+	switch n.Type {
+	case ElementNode:
+		children := make([]Element, len(n.Children))
+		for i, child := range n.Children {
+			children[i] = child
+		}
+		el := &htmlElement{
+			Namespace:  n.Namespace,
+			TagName:    n.TagName,
+			Attributes: n.Attributes,
+			Children:   children,
+			Void:       n.Void(),
+		}
+		return el.render(w)
+	case TextNode:
+		if _, err := io.WriteString(w, w.escapeText(n.Data)); err != nil {
+			return err
+		}
+	case RawNode:
+		if _, err := io.WriteString(w, n.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Node) appendNodes(nodes []*Node) ([]*Node, error) {
+	if n.Type == TextNode {
+		return appendText(nodes, n.Data), nil
+	}
+	return append(nodes, n), nil
+}
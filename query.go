@@ -0,0 +1,159 @@
+package m
+
+import (
+	"strings"
+
+	selectorpkg "layeh.com/m/internal/selector"
+)
+
+// Find returns the first node of the expanded element tree that matches
+// selector, or nil if no node matches.
+//
+// selector is a comma-separated list of CSS selectors. Each compound
+// selector uses the syntax of M, and compound selectors can be combined with
+// the descendant (whitespace) and child (>) combinators. In addition to
+// [key] and [key=value], attributes can be matched with the CSS operators
+// ~=, |=, ^=, $= and *=.
+//
+// A non-nil error is returned if selector is invalid (a *SelectorError) or if
+// element could not be expanded.
+func Find(element Element, selector string) (*Node, error) {
+	nodes, err := find(element, selector, true)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// FindAll returns the nodes of the expanded element tree that match
+// selector, in document order.
+//
+// See Find for the selector syntax.
+func FindAll(element Element, selector string) ([]*Node, error) {
+	return find(element, selector, false)
+}
+
+func find(element Element, selector string, first bool) ([]*Node, error) {
+	query, err := selectorpkg.ParseQuery(selector)
+	if err != nil {
+		return nil, selectorError(err)
+	}
+	nodes, err := Nodes(element)
+	if err != nil {
+		return nil, err
+	}
+	m := matcher{
+		Query: query,
+		First: first,
+	}
+	m.walk(nodes, nil)
+	return m.Matches, nil
+}
+
+type matcher struct {
+	Query   []selectorpkg.Complex
+	First   bool
+	Matches []*Node
+}
+
+func (m *matcher) walk(nodes []*Node, ancestors []*Node) bool {
+	for _, n := range nodes {
+		if n.Type != ElementNode {
+			continue
+		}
+		for _, complex := range m.Query {
+			if matchComplex(complex, n, ancestors) {
+				m.Matches = append(m.Matches, n)
+				if m.First {
+					return true
+				}
+				break
+			}
+		}
+		if m.walk(n.Children, append(ancestors, n)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchComplex(complex selectorpkg.Complex, n *Node, ancestors []*Node) bool {
+	last := len(complex) - 1
+	if !matchCompound(&complex[last], n) {
+		return false
+	}
+	if last == 0 {
+		return true
+	}
+	switch complex[last].Combinator {
+	case selectorpkg.Child:
+		if len(ancestors) == 0 {
+			return false
+		}
+		parent := len(ancestors) - 1
+		return matchComplex(complex[:last], ancestors[parent], ancestors[:parent])
+	default:
+		for i := len(ancestors) - 1; i >= 0; i-- {
+			if matchComplex(complex[:last], ancestors[i], ancestors[:i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func matchCompound(compound *selectorpkg.Compound, n *Node) bool {
+	if compound.TagName != "" && compound.TagName != "*" && !strings.EqualFold(compound.TagName, n.TagName) {
+		return false
+	}
+	if compound.ID != "" {
+		if id, _ := n.Attr("id"); id != compound.ID {
+			return false
+		}
+	}
+	if len(compound.Classes) > 0 {
+		classes, _ := n.Attr("class")
+		fields := strings.Fields(classes)
+		for _, class := range compound.Classes {
+			if !containsString(fields, class) {
+				return false
+			}
+		}
+	}
+	for _, attr := range compound.Attributes {
+		value, ok := n.Attr(attr.Key)
+		if !ok || !matchAttr(attr.Op, value, attr.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAttr(op byte, value, expected string) bool {
+	switch op {
+	case 0:
+		return true
+	case '=':
+		return value == expected
+	case '~':
+		return containsString(strings.Fields(value), expected)
+	case '|':
+		return value == expected || strings.HasPrefix(value, expected+"-")
+	case '^':
+		return expected != "" && strings.HasPrefix(value, expected)
+	case '$':
+		return expected != "" && strings.HasSuffix(value, expected)
+	case '*':
+		return expected != "" && strings.Contains(value, expected)
+	}
+	return false
+}
+
+func containsString(s []string, x string) bool {
+	for _, v := range s {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
@@ -1,29 +1,163 @@
 package m
 
 import (
+	"html"
 	"io"
 	"strings"
+	"text/template"
 )
 
 // Render writes the HTML of element to w.
 //
 // A non-nil error is returned if the element could not be successfully written.
 func Render(w io.Writer, element Element) error {
+	var r Renderer
+	return r.Render(w, element)
+}
+
+// RenderString returns the HTML of element.
+func RenderString(element Element) string {
+	var r Renderer
+	return r.RenderString(element)
+}
+
+// Renderer renders elements with non-default options.
+//
+// The zero value renders HTML, the same as Render.
+type Renderer struct {
+	// XML enables XML-compatible (XHTML) serialization. Void elements are
+	// self-closed (e.g. <br/>), characters that are not allowed in XML are
+	// removed from text and attribute values, and the outermost HTML
+	// elements declare the HTML namespace.
+	XML bool
+
+	// XMLDeclaration writes an XML declaration before the element when XML
+	// is enabled.
+	XMLDeclaration bool
+
+	// SortAttributes renders the attributes of elements sorted by name, so
+	// that the output does not depend on the order in which they are given,
+	// which is useful for golden files. Namespace declarations that are added
+	// when rendering are still written first.
+	//
+	// By default, attributes are rendered in the order id, class, and then
+	// the other attributes in the order that they are first given, in the
+	// selector and then in the elements of M.
+	SortAttributes bool
+}
+
+// Render writes element to w.
+//
+// A non-nil error is returned if the element could not be successfully written.
+func (r *Renderer) Render(w io.Writer, element Element) error {
+	rr := &renderer{
+		Writer:    w,
+		Renderer:  *r,
+		namespace: HTMLNamespace,
+	}
+	if r.XML {
+		// XHTML documents must declare the HTML namespace, so it is
+		// declared by the outermost HTML element.
+		rr.namespace = ""
+	}
+	if r.XML && r.XMLDeclaration {
+		if _, err := io.WriteString(w, xmlDeclaration); err != nil {
+			return err
+		}
+	}
+	return rr.render(element)
+}
+
+// RenderString returns element rendered as a string.
+func (r *Renderer) RenderString(element Element) string {
+	var b strings.Builder
+	r.Render(&b, element)
+	return b.String()
+}
+
+const xmlDeclaration = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+type renderer struct {
+	io.Writer
+	Renderer
+
+	// namespace is the default namespace in scope, and xlink is whether the
+	// xlink namespace has been declared.
+	namespace string
+	xlink     bool
+}
+
+// namespaceDeclaration returns the value of the xmlns attribute that e
+// needs, if any.
+func (r *renderer) namespaceDeclaration(e *htmlElement) (string, bool) {
+	if e.Namespace == r.namespace {
+		return "", false
+	}
+	for _, attr := range e.Attributes {
+		if attr.Key == "xmlns" {
+			return "", false
+		}
+	}
+	switch e.Namespace {
+	case HTMLNamespace:
+		// HTML parsers do not need a declaration to switch back to HTML
+		// from foreign content.
+		return HTMLNamespace, r.XML
+	case "":
+		// Elements without a namespace need a declaration only if they are
+		// inside of an element with one.
+		return "", r.namespace != HTMLNamespace
+	}
+	return e.Namespace, true
+}
+
+func (r *renderer) render(element Element) error {
 	if element == nil {
 		return nil
 	}
 	if internal, ok := element.(internalElement); ok {
-		if err := internal.renderHTML(w); err != nil {
+		if err := internal.render(r); err != nil {
 			return err
 		}
 		return nil
 	}
-	return Render(w, element.Element())
+	return r.render(element.Element())
 }
 
-// RenderString returns the HTML of element.
-func RenderString(element Element) string {
-	var b strings.Builder
-	Render(&b, element)
-	return b.String()
+func (r *renderer) escapeText(s string) string {
+	if r.XML {
+		s = removeInvalidXML(s)
+	}
+	return html.EscapeString(s)
+}
+
+var xmlWhitespaceReplacer = strings.NewReplacer(
+	"\t", "&#x9;",
+	"\n", "&#xA;",
+	"\r", "&#xD;",
+)
+
+func (r *renderer) escapeAttr(s string) string {
+	if r.XML {
+		// Escape whitespace so that it is not normalized to spaces by XML
+		// parsers.
+		return xmlWhitespaceReplacer.Replace(template.HTMLEscapeString(removeInvalidXML(s)))
+	}
+	return template.HTMLEscapeString(s)
+}
+
+// removeInvalidXML returns s without the characters that are not allowed in
+// XML documents.
+func removeInvalidXML(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t', r == '\n', r == '\r':
+			return r
+		case r < 0x20:
+			return -1
+		case r >= 0xD800 && r <= 0xDFFF, r == 0xFFFE, r == 0xFFFF:
+			return -1
+		}
+		return r
+	}, s)
 }
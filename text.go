@@ -0,0 +1,237 @@
+package m
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"layeh.com/m/internal/lines"
+)
+
+// RenderText writes a plain text representation of element to w, such as
+// for the text/plain part of an email.
+//
+// Block elements are separated by line breaks, list items are prefixed
+// with bullets or numbers, links are written as "text (url)", tables are
+// written as aligned columns, and images are replaced by their alt text.
+// Whitespace is collapsed outside of pre elements, and tags are removed
+// from raw HTML.
+//
+// A non-nil error is returned if the element could not be successfully written.
+func RenderText(w io.Writer, element Element) error {
+	nodes, err := Nodes(element)
+	if err != nil {
+		return err
+	}
+	var t textWriter
+	t.nodes(nodes)
+	if t.B.Len() > 0 {
+		t.B.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, t.String())
+	return err
+}
+
+type textWriter struct {
+	lines.Writer
+
+	pre   bool
+	lists int
+}
+
+func (t *textWriter) String() string {
+	return t.B.String()
+}
+
+func (t *textWriter) text(s string) {
+	if t.pre {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				t.Block(t.Breaks + 1)
+			}
+			t.Write(line)
+		}
+		return
+	}
+	t.Words(s, nil)
+}
+
+func (t *textWriter) nodes(nodes []*Node) {
+	for _, n := range nodes {
+		switch n.Type {
+		case TextNode:
+			t.text(n.Data)
+		case RawNode:
+			t.text(lines.RawText(n.Data))
+		case ElementNode:
+			t.element(n)
+		}
+	}
+}
+
+func (t *textWriter) element(n *Node) {
+	tagName := strings.ToLower(n.TagName)
+	if lines.Skipped[tagName] {
+		return
+	}
+	breaks := lines.BlockBreaks(tagName, t.lists > 0)
+	t.Block(breaks)
+
+	switch tagName {
+	case "br":
+		t.Block(t.Breaks + 1)
+	case "hr":
+		t.Write("----")
+	case "img":
+		alt, _ := n.Attr("alt")
+		t.text(alt)
+	case "a":
+		t.link(n)
+	case "pre":
+		pre := t.pre
+		t.pre = true
+		t.nodes(n.Children)
+		t.pre = pre
+	case "ul", "ol":
+		t.list(n, tagName == "ol")
+	case "blockquote":
+		prefix := t.Prefix
+		t.Prefix += "> "
+		t.nodes(n.Children)
+		t.Prefix = prefix
+	case "table":
+		t.table(n)
+	default:
+		t.nodes(n.Children)
+	}
+
+	t.Block(breaks)
+}
+
+func (t *textWriter) link(n *Node) {
+	href, _ := n.Attr("href")
+	var inner textWriter
+	inner.pre = t.pre
+	inner.nodes(n.Children)
+	text := inner.String()
+
+	t.text(text)
+	if href != "" && !strings.HasPrefix(href, "#") && href != text {
+		if text == "" {
+			t.text(href)
+		} else {
+			t.text(" (" + href + ")")
+		}
+	}
+}
+
+func (t *textWriter) list(n *Node, ordered bool) {
+	number := 1
+	if start, ok := n.Attr("start"); ok && ordered {
+		if i, err := strconv.Atoi(start); err == nil {
+			number = i
+		}
+	}
+
+	t.lists++
+	defer func() {
+		t.lists--
+	}()
+
+	prefix := t.Prefix
+	for _, child := range n.Children {
+		if child.Type != ElementNode || !strings.EqualFold(child.TagName, "li") {
+			t.nodes([]*Node{child})
+			continue
+		}
+		bullet := "- "
+		if ordered {
+			bullet = strconv.Itoa(number) + ". "
+			number++
+		}
+		t.Block(1)
+		t.Marker = prefix + bullet
+		t.Prefix = prefix + strings.Repeat(" ", len(bullet))
+		t.nodes(child.Children)
+		t.Prefix = prefix
+		t.Marker = ""
+		t.Block(1)
+	}
+}
+
+func (t *textWriter) table(n *Node) {
+	var rows [][]string
+	var header bool
+	var walk func(nodes []*Node)
+	walk = func(nodes []*Node) {
+		for _, n := range nodes {
+			if n.Type != ElementNode {
+				continue
+			}
+			switch strings.ToLower(n.TagName) {
+			case "tr":
+				var row []string
+				for _, cell := range n.Children {
+					if cell.Type != ElementNode {
+						continue
+					}
+					tagName := strings.ToLower(cell.TagName)
+					if tagName != "td" && tagName != "th" {
+						continue
+					}
+					if tagName == "th" && len(rows) == 0 {
+						header = true
+					}
+					var inner textWriter
+					inner.nodes(cell.Children)
+					row = append(row, strings.Join(strings.Fields(inner.String()), " "))
+				}
+				rows = append(rows, row)
+			case "caption":
+				var inner textWriter
+				inner.nodes(n.Children)
+				t.text(inner.String())
+				t.Block(1)
+			default:
+				walk(n.Children)
+			}
+		}
+	}
+	walk(n.Children)
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := utf8.RuneCountInString(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	writeRow := func(row []string) {
+		var b strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		t.Block(1)
+		t.Write(strings.TrimRight(b.String(), " "))
+	}
+	for i, row := range rows {
+		writeRow(row)
+		if i == 0 && header {
+			separator := make([]string, len(widths))
+			for i, w := range widths {
+				separator[i] = strings.Repeat("-", w)
+			}
+			writeRow(separator)
+		}
+	}
+}